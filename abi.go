@@ -0,0 +1,159 @@
+package solc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ABIEntry is a single function, constructor, event, error, fallback or
+// receive definition of a contract ABI.
+type ABIEntry struct {
+	Type            string         `json:"type"`
+	Name            string         `json:"name,omitempty"`
+	Inputs          []ABIParameter `json:"inputs,omitempty"`
+	Outputs         []ABIParameter `json:"outputs,omitempty"`
+	StateMutability string         `json:"stateMutability,omitempty"`
+	Anonymous       bool           `json:"anonymous,omitempty"`
+}
+
+// ABIParameter is an input or output parameter of an ABI entry.
+type ABIParameter struct {
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	InternalType string         `json:"internalType,omitempty"`
+	Components   []ABIParameter `json:"components,omitempty"`
+	// Indexed is only set for event parameters.
+	Indexed bool `json:"indexed,omitempty"`
+}
+
+// ParsedABI decodes the raw ABI of the contract into typed entries.
+func (c *Contract) ParsedABI() ([]ABIEntry, error) {
+	entries := make([]ABIEntry, 0, len(c.ABI))
+	for i, raw := range c.ABI {
+		var entry ABIEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse ABI entry %d: %w", i, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Events returns the event definitions of the contract ABI, e.g. for log
+// decoding. Entries that cannot be parsed are skipped.
+func (c *Contract) Events() []ABIEntry {
+	return c.abiEntriesOfType("event")
+}
+
+// CustomErrors returns the custom error definitions of the contract ABI, e.g.
+// for revert decoding. Entries that cannot be parsed are skipped.
+func (c *Contract) CustomErrors() []ABIEntry {
+	return c.abiEntriesOfType("error")
+}
+
+// abiEntriesOfType returns the parsed ABI entries with the given type.
+func (c *Contract) abiEntriesOfType(entryType string) []ABIEntry {
+	var entries []ABIEntry
+	for _, raw := range c.ABI {
+		var entry ABIEntry
+		if err := json.Unmarshal(raw, &entry); err != nil || entry.Type != entryType {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Signature returns the canonical signature of the entry, such as
+// "Transfer(address,address,uint256)", with tuples expanded to their
+// component types.
+func (e ABIEntry) Signature() string {
+	return e.Name + "(" + canonicalTypes(e.Inputs) + ")"
+}
+
+// Hash returns the Keccak-256 hash of the entry's signature. For events this
+// is the first topic of their (non-anonymous) logs.
+func (e ABIEntry) Hash() [32]byte {
+	var hash [32]byte
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(e.Signature()))
+	h.Sum(hash[:0])
+	return hash
+}
+
+// Selector returns the first four bytes of the signature hash, which identify
+// a function in call data and a custom error in revert data.
+func (e ABIEntry) Selector() [4]byte {
+	var selector [4]byte
+	hash := e.Hash()
+	copy(selector[:], hash[:4])
+	return selector
+}
+
+// canonicalTypes joins the canonical types of the parameters with commas.
+func canonicalTypes(params []ABIParameter) string {
+	types := make([]string, len(params))
+	for i, param := range params {
+		types[i] = param.canonicalType()
+	}
+	return strings.Join(types, ",")
+}
+
+// canonicalType returns the type as used in signatures, replacing "tuple" by
+// the parenthesized component types while keeping array suffixes.
+func (p ABIParameter) canonicalType() string {
+	if !strings.HasPrefix(p.Type, "tuple") {
+		return p.Type
+	}
+	return "(" + canonicalTypes(p.Components) + ")" + strings.TrimPrefix(p.Type, "tuple")
+}
+
+// CompileABI compiles the sources with the given compiler version, selecting
+// only the ABI output, and returns the ABI of every contract keyed by
+// "file:contract". It returns an error if compilation reports any error.
+//
+// solc's stopAfter setting only supports stopping after parsing, which is
+// before the ABI is available, so a full analysis is still performed; code
+// generation is skipped since no bytecode is selected.
+func CompileABI(version string, sources map[string]SourceIn) (map[string][]ABIEntry, error) {
+	compiler, err := NewWithVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	defer compiler.Close()
+
+	output, err := compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources:  sources,
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range output.Errors {
+		if e.IsError() {
+			return nil, fmt.Errorf("compilation failed: %s", e.FormattedMessage)
+		}
+	}
+
+	abis := make(map[string][]ABIEntry)
+	for file, contracts := range output.Contracts {
+		for name, contract := range contracts {
+			entries, err := contract.ParsedABI()
+			if err != nil {
+				return nil, fmt.Errorf("%s:%s: %w", file, name, err)
+			}
+			abis[file+":"+name] = entries
+		}
+	}
+
+	return abis, nil
+}
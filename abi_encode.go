@@ -0,0 +1,342 @@
+package solc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// arrayTypePattern splits an array type like "uint256[3]" into its element type and length.
+var arrayTypePattern = regexp.MustCompile(`^(.*)\[(\d*)\]$`)
+
+// EncodeDeployment returns the creation bytecode of the contract with the
+// ABI-encoded constructor arguments appended, ready to be sent as the data of a
+// contract creation transaction. Contracts without a constructor accept no
+// arguments and get their bytecode returned as-is.
+//
+// Supported Go values are *big.Int and the built-in integer types for
+// (u)intN, bool, string, []byte or byte arrays for bytes and bytesN, a
+// [20]byte or hex string for address, slices or arrays for T[] and T[k], and
+// []interface{} or structs (in field order) for tuples.
+func EncodeDeployment(contract *Contract, args ...interface{}) ([]byte, error) {
+	if contract == nil {
+		return nil, fmt.Errorf("contract cannot be nil")
+	}
+
+	bytecode, err := contract.CreationBytecode()
+	if err != nil {
+		return nil, err
+	}
+
+	abi, err := contract.ParsedABI()
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []ABIParameter
+	for _, entry := range abi {
+		if entry.Type == "constructor" {
+			inputs = entry.Inputs
+			break
+		}
+	}
+
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("constructor expects %d arguments, got %d", len(inputs), len(args))
+	}
+	if len(inputs) == 0 {
+		return bytecode, nil
+	}
+
+	encoded, err := encodeTuple(inputs, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode constructor arguments: %w", err)
+	}
+
+	return append(bytecode, encoded...), nil
+}
+
+// encodeTuple ABI-encodes values as a tuple of the given parameter types.
+func encodeTuple(params []ABIParameter, values []interface{}) ([]byte, error) {
+	if len(params) != len(values) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(params), len(values))
+	}
+
+	heads := make([][]byte, len(params))
+	tails := make([][]byte, len(params))
+	headSize := 0
+	for i, param := range params {
+		encoded, err := encodeValue(param, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, param.Type, err)
+		}
+		if isDynamicType(param) {
+			tails[i] = encoded
+			headSize += 32
+		} else {
+			heads[i] = encoded
+			headSize += len(encoded)
+		}
+	}
+
+	var head, tail []byte
+	for i, param := range params {
+		if isDynamicType(param) {
+			head = append(head, encodeUint(big.NewInt(int64(headSize+len(tail))))...)
+			tail = append(tail, tails[i]...)
+		} else {
+			head = append(head, heads[i]...)
+		}
+	}
+
+	return append(head, tail...), nil
+}
+
+// encodeValue ABI-encodes a single value of the given parameter type.
+func encodeValue(param ABIParameter, value interface{}) ([]byte, error) {
+	if match := arrayTypePattern.FindStringSubmatch(param.Type); match != nil {
+		elem := ABIParameter{Type: match[1], Components: param.Components}
+		items, err := toSlice(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if match[2] != "" {
+			length, _ := strconv.Atoi(match[2])
+			if len(items) != length {
+				return nil, fmt.Errorf("expected %d array elements, got %d", length, len(items))
+			}
+		}
+
+		elems := make([]ABIParameter, len(items))
+		for i := range items {
+			elems[i] = elem
+		}
+		encoded, err := encodeTuple(elems, items)
+		if err != nil {
+			return nil, err
+		}
+		if match[2] == "" {
+			return append(encodeUint(big.NewInt(int64(len(items)))), encoded...), nil
+		}
+		return encoded, nil
+	}
+
+	switch {
+	case param.Type == "tuple":
+		fields, err := toFields(value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTuple(param.Components, fields)
+
+	case param.Type == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %T", value)
+		}
+		if b {
+			return encodeUint(big.NewInt(1)), nil
+		}
+		return encodeUint(big.NewInt(0)), nil
+
+	case param.Type == "address":
+		addr, err := toBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(addr) != 20 {
+			return nil, fmt.Errorf("address must be 20 bytes, got %d", len(addr))
+		}
+		return leftPad(addr), nil
+
+	case param.Type == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", value)
+		}
+		return encodeBytes([]byte(s)), nil
+
+	case param.Type == "bytes":
+		b, err := toBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeBytes(b), nil
+
+	case strings.HasPrefix(param.Type, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(param.Type, "bytes"))
+		if err != nil || size < 1 || size > 32 {
+			return nil, fmt.Errorf("unsupported type %s", param.Type)
+		}
+		b, err := toBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+		}
+		return rightPad(b), nil
+
+	case strings.HasPrefix(param.Type, "uint"), strings.HasPrefix(param.Type, "int"):
+		signed := strings.HasPrefix(param.Type, "int")
+		bits := 256
+		if suffix := strings.TrimPrefix(strings.TrimPrefix(param.Type, "u"), "int"); suffix != "" {
+			var err error
+			if bits, err = strconv.Atoi(suffix); err != nil || bits%8 != 0 || bits < 8 || bits > 256 {
+				return nil, fmt.Errorf("unsupported type %s", param.Type)
+			}
+		}
+		n, err := toBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeInt(n, bits, signed)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", param.Type)
+}
+
+// isDynamicType reports whether a type is encoded in the tail section of a tuple.
+func isDynamicType(param ABIParameter) bool {
+	if match := arrayTypePattern.FindStringSubmatch(param.Type); match != nil {
+		return match[2] == "" || isDynamicType(ABIParameter{Type: match[1], Components: param.Components})
+	}
+	switch param.Type {
+	case "string", "bytes":
+		return true
+	case "tuple":
+		for _, component := range param.Components {
+			if isDynamicType(component) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encodeInt encodes an integer as a 32-byte two's complement word after checking its range.
+func encodeInt(n *big.Int, bits int, signed bool) ([]byte, error) {
+	if signed {
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("value %s overflows int%d", n, bits)
+		}
+		if n.Sign() < 0 {
+			n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return encodeUint(n), nil
+	}
+
+	if n.Sign() < 0 || n.BitLen() > bits {
+		return nil, fmt.Errorf("value %s overflows uint%d", n, bits)
+	}
+	return encodeUint(n), nil
+}
+
+// encodeUint encodes a non-negative integer as a 32-byte big-endian word.
+func encodeUint(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, 32))
+}
+
+// encodeBytes encodes a dynamic byte string as its length followed by the padded data.
+func encodeBytes(b []byte) []byte {
+	return append(encodeUint(big.NewInt(int64(len(b)))), rightPad(b)...)
+}
+
+// leftPad pads b with leading zeros to a multiple of 32 bytes.
+func leftPad(b []byte) []byte {
+	padded := make([]byte, (len(b)+31)/32*32)
+	copy(padded[len(padded)-len(b):], b)
+	return padded
+}
+
+// rightPad pads b with trailing zeros to a multiple of 32 bytes.
+func rightPad(b []byte) []byte {
+	padded := make([]byte, (len(b)+31)/32*32)
+	copy(padded, b)
+	return padded
+}
+
+// toBigInt converts a Go integer value to a big.Int.
+func toBigInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, fmt.Errorf("nil *big.Int")
+		}
+		return v, nil
+	case big.Int:
+		return &v, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return nil, fmt.Errorf("expected integer, got %T", value)
+}
+
+// toBytes converts a byte slice, byte array or hex string to a byte slice.
+func toBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		b, err := hex.DecodeString(strings.TrimPrefix(v, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex string: %w", err)
+		}
+		return b, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return b, nil
+	}
+	return nil, fmt.Errorf("expected bytes, got %T", value)
+}
+
+// toSlice converts a Go slice or array to a slice of its elements.
+func toSlice(value interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected slice or array, got %T", value)
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// toFields converts a []interface{} or struct to its ordered tuple fields.
+func toFields(value interface{}) ([]interface{}, error) {
+	if fields, ok := value.([]interface{}); ok {
+		return fields, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct or []interface{} for tuple, got %T", value)
+	}
+	fields := make([]interface{}, 0, rv.NumField())
+	for i := 0; i < rv.NumField(); i++ {
+		if rv.Type().Field(i).IsExported() {
+			fields = append(fields, rv.Field(i).Interface())
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,92 @@
+package solc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const constructorContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Greeter {
+    uint256 public count;
+    string public greeting;
+
+    constructor(uint256 _count, string memory _greeting) {
+        count = _count;
+        greeting = _greeting;
+    }
+}
+
+contract NoConstructor {
+    function one() public pure returns (uint256) {
+        return 1;
+    }
+}
+`
+
+func TestEncodeDeployment(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "abi", "evm.bytecode.object")
+	require.Empty(t, output.Errors)
+
+	greeter := output.Contracts["Greeter.sol"]["Greeter"]
+	data, err := EncodeDeployment(&greeter, big.NewInt(42), "hi")
+	require.NoError(t, err)
+
+	expectedArgs := strings.Join([]string{
+		"000000000000000000000000000000000000000000000000000000000000002a",
+		"0000000000000000000000000000000000000000000000000000000000000040",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"6869000000000000000000000000000000000000000000000000000000000000",
+	}, "")
+	assert.Equal(t, greeter.EVM.Bytecode.Object+expectedArgs, hex.EncodeToString(data))
+
+	// Argument count must match the constructor
+	_, err = EncodeDeployment(&greeter, 42)
+	assert.Error(t, err)
+
+	// Contracts without a constructor return the plain bytecode
+	noConstructor := output.Contracts["Greeter.sol"]["NoConstructor"]
+	data, err = EncodeDeployment(&noConstructor)
+	require.NoError(t, err)
+	assert.Equal(t, noConstructor.EVM.Bytecode.Object, hex.EncodeToString(data))
+}
+
+func TestEncodeTuple(t *testing.T) {
+	params := []ABIParameter{
+		{Type: "int8"},
+		{Type: "address"},
+		{Type: "uint16[]"},
+		{Type: "bytes2"},
+	}
+	var addr [20]byte
+	addr[19] = 0x01
+
+	encoded, err := encodeTuple(params, []interface{}{-1, addr, []uint16{1, 2}, []byte{0xab, 0xcd}})
+	require.NoError(t, err)
+
+	expected := strings.Join([]string{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"abcd000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+	}, "")
+	assert.Equal(t, expected, hex.EncodeToString(encoded))
+
+	// Out of range values are rejected
+	_, err = encodeTuple([]ABIParameter{{Type: "uint8"}}, []interface{}{256})
+	assert.Error(t, err)
+	_, err = encodeTuple([]ABIParameter{{Type: "int8"}}, []interface{}{-129})
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package solc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const abiContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Token {
+    struct Point {
+        uint256 x;
+        uint256 y;
+    }
+
+    event Transfer(address indexed from, address indexed to, uint256 value);
+
+    error Insufficient(uint256 available, uint256 required);
+
+    constructor(string memory name) {}
+
+    function move(Point calldata p) external pure returns (Point memory) {
+        return p;
+    }
+
+    receive() external payable {}
+}
+`
+
+func TestParsedABI(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Token.sol": {Content: abiContract},
+	}, "abi")
+	require.Empty(t, output.Errors)
+
+	contract := output.Contracts["Token.sol"]["Token"]
+	entries, err := contract.ParsedABI()
+	require.NoError(t, err)
+	require.Len(t, entries, len(contract.ABI), "Raw ABI should be preserved")
+
+	byType := map[string]ABIEntry{}
+	for _, entry := range entries {
+		byType[entry.Type] = entry
+	}
+
+	require.Contains(t, byType, "constructor")
+	assert.Equal(t, "nonpayable", byType["constructor"].StateMutability)
+	assert.Equal(t, "string", byType["constructor"].Inputs[0].Type)
+
+	require.Contains(t, byType, "function")
+	move := byType["function"]
+	assert.Equal(t, "move", move.Name)
+	assert.Equal(t, "pure", move.StateMutability)
+	require.Len(t, move.Inputs, 1)
+	assert.Equal(t, "tuple", move.Inputs[0].Type)
+	assert.Equal(t, "struct Token.Point", move.Inputs[0].InternalType)
+	assert.Len(t, move.Inputs[0].Components, 2)
+	require.Len(t, move.Outputs, 1)
+
+	require.Contains(t, byType, "event")
+	transfer := byType["event"]
+	assert.Equal(t, "Transfer", transfer.Name)
+	assert.False(t, transfer.Anonymous)
+	assert.True(t, transfer.Inputs[0].Indexed)
+	assert.False(t, transfer.Inputs[2].Indexed)
+
+	require.Contains(t, byType, "error")
+	assert.Equal(t, "Insufficient", byType["error"].Name)
+
+	require.Contains(t, byType, "receive")
+	assert.Equal(t, "payable", byType["receive"].StateMutability)
+}
+
+func TestEventsAndCustomErrors(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Token.sol": {Content: abiContract},
+	}, "abi", "evm.methodIdentifiers")
+	require.Empty(t, output.Errors)
+
+	contract := output.Contracts["Token.sol"]["Token"]
+
+	events := contract.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "Transfer(address,address,uint256)", events[0].Signature())
+	topic := events[0].Hash()
+	assert.Equal(t, "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", hex.EncodeToString(topic[:]))
+
+	customErrors := contract.CustomErrors()
+	require.Len(t, customErrors, 1)
+	assert.Equal(t, "Insufficient(uint256,uint256)", customErrors[0].Signature())
+
+	// The builtin Error(string) revert reason has the well-known selector
+	builtin := ABIEntry{Type: "error", Name: "Error", Inputs: []ABIParameter{{Type: "string"}}}
+	selector := builtin.Selector()
+	assert.Equal(t, "08c379a0", hex.EncodeToString(selector[:]))
+
+	// Tuples are expanded, matching the selectors computed by solc
+	entries, err := contract.ParsedABI()
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Type != "function" {
+			continue
+		}
+		assert.Equal(t, "move((uint256,uint256))", entry.Signature())
+		selector := entry.Selector()
+		assert.Equal(t, contract.EVM.MethodIdentifiers[entry.Signature()], hex.EncodeToString(selector[:]))
+	}
+}
+
+func TestCanonicalTypes(t *testing.T) {
+	params := []ABIParameter{
+		{Type: "uint256"},
+		{Type: "tuple[2][]", Components: []ABIParameter{
+			{Type: "address"},
+			{Type: "tuple", Components: []ABIParameter{{Type: "bytes32"}, {Type: "bool[]"}}},
+		}},
+	}
+	assert.Equal(t, "uint256,(address,(bytes32,bool[]))[2][]", canonicalTypes(params))
+	assert.Equal(t, "", canonicalTypes(nil))
+}
+
+func TestCompileABI(t *testing.T) {
+	abis, err := CompileABI("0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	})
+	require.NoError(t, err)
+	require.Contains(t, abis, "Greeter.sol:Greeter")
+	require.Contains(t, abis, "Greeter.sol:NoConstructor")
+
+	names := []string{}
+	for _, entry := range abis["Greeter.sol:Greeter"] {
+		names = append(names, entry.Name)
+	}
+	assert.ElementsMatch(t, []string{"", "count", "greeting"}, names)
+
+	_, err = CompileABI("0.8.21", map[string]SourceIn{
+		"Broken.sol": {Content: "pragma solidity ^0.8.0; contract Broken {"},
+	})
+	assert.ErrorContains(t, err, "compilation failed")
+}
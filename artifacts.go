@@ -0,0 +1,177 @@
+package solc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ArtifactFormat selects the layout of the artifacts written by WriteArtifacts.
+type ArtifactFormat int
+
+const (
+	// ArtifactFormatHardhat writes Hardhat artifacts ("hh-sol-artifact-1") to
+	// <dir>/<source name>/<contract>.json, as in Hardhat's artifacts directory.
+	ArtifactFormatHardhat ArtifactFormat = iota
+	// ArtifactFormatFoundry writes Foundry artifacts to
+	// <dir>/<source file name>/<contract>.json, as in Foundry's out directory.
+	ArtifactFormatFoundry
+)
+
+// HardhatArtifact is a per-contract artifact in Hardhat's format. Bytecodes
+// are 0x-prefixed.
+type HardhatArtifact struct {
+	Format                 string                          `json:"_format"`
+	ContractName           string                          `json:"contractName"`
+	SourceName             string                          `json:"sourceName"`
+	ABI                    []json.RawMessage               `json:"abi"`
+	Bytecode               string                          `json:"bytecode"`
+	DeployedBytecode       string                          `json:"deployedBytecode"`
+	LinkReferences         map[string]map[string][]LinkRef `json:"linkReferences"`
+	DeployedLinkReferences map[string]map[string][]LinkRef `json:"deployedLinkReferences"`
+}
+
+// FoundryArtifact is a per-contract artifact in Foundry's format. Bytecode
+// objects are 0x-prefixed.
+type FoundryArtifact struct {
+	ABI               []json.RawMessage       `json:"abi"`
+	Bytecode          FoundryBytecode         `json:"bytecode"`
+	DeployedBytecode  FoundryDeployedBytecode `json:"deployedBytecode"`
+	MethodIdentifiers map[string]string       `json:"methodIdentifiers"`
+	RawMetadata       string                  `json:"rawMetadata,omitempty"`
+	Metadata          json.RawMessage         `json:"metadata,omitempty"`
+	StorageLayout     *StorageLayout          `json:"storageLayout,omitempty"`
+	ID                int                     `json:"id"`
+}
+
+// FoundryBytecode is the creation bytecode of a Foundry artifact.
+type FoundryBytecode struct {
+	Object         string                          `json:"object"`
+	SourceMap      string                          `json:"sourceMap"`
+	LinkReferences map[string]map[string][]LinkRef `json:"linkReferences"`
+}
+
+// FoundryDeployedBytecode is the runtime bytecode of a Foundry artifact.
+type FoundryDeployedBytecode struct {
+	FoundryBytecode
+	ImmutableReferences map[string][]Offset `json:"immutableReferences"`
+}
+
+// WriteArtifacts writes one JSON artifact per contract of the output to dir
+// in the given format, creating directories as needed. Fields that were not
+// part of the output selection are written empty, so select at least abi,
+// evm.bytecode, evm.deployedBytecode and, for Foundry, metadata.
+func WriteArtifacts(output *Output, dir string, format ArtifactFormat) error {
+	if output == nil {
+		return fmt.Errorf("output cannot be nil")
+	}
+	if format != ArtifactFormatHardhat && format != ArtifactFormatFoundry {
+		return fmt.Errorf("unknown artifact format %d", format)
+	}
+
+	var err error
+	output.Range(func(file, name string, c *Contract) bool {
+		var artifact any
+		var artifactDir string
+		if format == ArtifactFormatHardhat {
+			artifact = newHardhatArtifact(file, name, c)
+			artifactDir = filepath.Join(dir, filepath.FromSlash(file))
+		} else {
+			artifact = newFoundryArtifact(c, output.Sources[file].ID)
+			artifactDir = filepath.Join(dir, path.Base(file))
+		}
+		err = writeArtifact(filepath.Join(artifactDir, name+".json"), artifact)
+		return err == nil
+	})
+	return err
+}
+
+// newHardhatArtifact converts a contract of the given source into a Hardhat artifact.
+func newHardhatArtifact(file, name string, c *Contract) HardhatArtifact {
+	return HardhatArtifact{
+		Format:                 "hh-sol-artifact-1",
+		ContractName:           name,
+		SourceName:             file,
+		ABI:                    artifactABI(c),
+		Bytecode:               hexPrefixed(c.EVM.Bytecode.Object),
+		DeployedBytecode:       hexPrefixed(c.EVM.DeployedBytecode.Object),
+		LinkReferences:         artifactLinkReferences(c.EVM.Bytecode.LinkReferences),
+		DeployedLinkReferences: artifactLinkReferences(c.EVM.DeployedBytecode.LinkReferences),
+	}
+}
+
+// newFoundryArtifact converts a contract of the source with the given ID into a Foundry artifact.
+func newFoundryArtifact(c *Contract, id int) FoundryArtifact {
+	artifact := FoundryArtifact{
+		ABI: artifactABI(c),
+		Bytecode: FoundryBytecode{
+			Object:         hexPrefixed(c.EVM.Bytecode.Object),
+			SourceMap:      c.EVM.Bytecode.SourceMap,
+			LinkReferences: artifactLinkReferences(c.EVM.Bytecode.LinkReferences),
+		},
+		DeployedBytecode: FoundryDeployedBytecode{
+			FoundryBytecode: FoundryBytecode{
+				Object:         hexPrefixed(c.EVM.DeployedBytecode.Object),
+				SourceMap:      c.EVM.DeployedBytecode.SourceMap,
+				LinkReferences: artifactLinkReferences(c.EVM.DeployedBytecode.LinkReferences),
+			},
+			ImmutableReferences: c.EVM.DeployedBytecode.ImmutableReferences,
+		},
+		MethodIdentifiers: c.EVM.MethodIdentifiers,
+		RawMetadata:       c.Metadata,
+		StorageLayout:     c.StorageLayout,
+		ID:                id,
+	}
+	if artifact.DeployedBytecode.ImmutableReferences == nil {
+		artifact.DeployedBytecode.ImmutableReferences = map[string][]Offset{}
+	}
+	if artifact.MethodIdentifiers == nil {
+		artifact.MethodIdentifiers = map[string]string{}
+	}
+	if json.Valid([]byte(c.Metadata)) {
+		artifact.Metadata = json.RawMessage(c.Metadata)
+	}
+	return artifact
+}
+
+// writeArtifact writes an artifact as indented JSON, creating its directory.
+func writeArtifact(name string, artifact any) error {
+	data, err := json.MarshalIndent(artifact, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal artifact %s: %w", name, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", name, err)
+	}
+	if err := os.WriteFile(name, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", name, err)
+	}
+	return nil
+}
+
+// artifactABI returns the ABI of the contract, empty rather than nil.
+func artifactABI(c *Contract) []json.RawMessage {
+	if c.ABI == nil {
+		return []json.RawMessage{}
+	}
+	return c.ABI
+}
+
+// artifactLinkReferences returns the link references, empty rather than nil.
+func artifactLinkReferences(references map[string]map[string][]LinkRef) map[string]map[string][]LinkRef {
+	if references == nil {
+		return map[string]map[string][]LinkRef{}
+	}
+	return references
+}
+
+// hexPrefixed prefixes a bytecode object with 0x. An empty object becomes
+// "0x", as in artifacts of abstract contracts.
+func hexPrefixed(object string) string {
+	if len(object) >= 2 && object[:2] == "0x" {
+		return object
+	}
+	return "0x" + object
+}
@@ -0,0 +1,68 @@
+package solc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteArtifacts(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"contracts/Greeter.sol": {Content: constructorContract},
+		"Shop.sol":              {Content: externalLibraryContract},
+	}, "abi", "evm.bytecode", "evm.deployedBytecode", "evm.methodIdentifiers", "metadata")
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	greeter := output.Contracts["contracts/Greeter.sol"]["Greeter"]
+
+	// Hardhat artifacts round-trip the contract
+	dir := t.TempDir()
+	require.NoError(t, WriteArtifacts(output, dir, ArtifactFormatHardhat))
+	data, err := os.ReadFile(filepath.Join(dir, "contracts", "Greeter.sol", "Greeter.json"))
+	require.NoError(t, err)
+	var hardhat HardhatArtifact
+	require.NoError(t, json.Unmarshal(data, &hardhat))
+	assert.Equal(t, "hh-sol-artifact-1", hardhat.Format)
+	assert.Equal(t, "Greeter", hardhat.ContractName)
+	assert.Equal(t, "contracts/Greeter.sol", hardhat.SourceName)
+	assertSameJSON(t, greeter.ABI, hardhat.ABI)
+	assert.Equal(t, "0x"+greeter.EVM.Bytecode.Object, hardhat.Bytecode)
+	assert.Equal(t, "0x"+greeter.EVM.DeployedBytecode.Object, hardhat.DeployedBytecode)
+	assert.Empty(t, hardhat.LinkReferences)
+
+	data, err = os.ReadFile(filepath.Join(dir, "Shop.sol", "Shop.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &hardhat))
+	assert.Contains(t, hardhat.LinkReferences["Shop.sol"], "Pricing")
+
+	// Foundry artifacts are grouped by file name and carry the metadata
+	dir = t.TempDir()
+	require.NoError(t, WriteArtifacts(output, dir, ArtifactFormatFoundry))
+	data, err = os.ReadFile(filepath.Join(dir, "Greeter.sol", "Greeter.json"))
+	require.NoError(t, err)
+	var foundry FoundryArtifact
+	require.NoError(t, json.Unmarshal(data, &foundry))
+	assertSameJSON(t, greeter.ABI, foundry.ABI)
+	assert.Equal(t, "0x"+greeter.EVM.Bytecode.Object, foundry.Bytecode.Object)
+	assert.Equal(t, greeter.EVM.DeployedBytecode.SourceMap, foundry.DeployedBytecode.SourceMap)
+	assert.Equal(t, greeter.EVM.MethodIdentifiers, foundry.MethodIdentifiers)
+	assert.Equal(t, greeter.Metadata, foundry.RawMetadata)
+	assert.JSONEq(t, greeter.Metadata, string(foundry.Metadata))
+	assert.Equal(t, output.Sources["contracts/Greeter.sol"].ID, foundry.ID)
+
+	assert.Error(t, WriteArtifacts(output, dir, ArtifactFormat(42)))
+	assert.Error(t, WriteArtifacts(nil, dir, ArtifactFormatHardhat))
+}
+
+// assertSameJSON asserts that two values marshal to equivalent JSON.
+func assertSameJSON(t *testing.T, expected, actual any) {
+	t.Helper()
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
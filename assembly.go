@@ -0,0 +1,106 @@
+package solc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LegacyAssembly is the evm.legacyAssembly output: the assembly items of the
+// legacy code generator together with nested sub-assemblies such as the
+// runtime code of a contract.
+type LegacyAssembly struct {
+	// Code lists the assembly items in order.
+	Code []AssemblyItem
+	// SubAssemblies holds the nested assemblies from ".data", keyed by index.
+	SubAssemblies map[string]*LegacyAssembly
+	// Data holds raw hex data entries from ".data", keyed by their hash.
+	Data map[string]string
+	// AuxData is the hex-encoded CBOR metadata appended to the code.
+	AuxData string
+	// SourceList maps the Source indices of the items to source names. It is
+	// only set on the top-level assembly.
+	SourceList []string
+}
+
+// AssemblyItem is a single instruction, tag or push in a legacy assembly.
+type AssemblyItem struct {
+	Begin         int    `json:"begin"`
+	End           int    `json:"end"`
+	Name          string `json:"name"`
+	Source        int    `json:"source"`
+	Value         string `json:"value,omitempty"`
+	JumpType      string `json:"jumpType,omitempty"`
+	ModifierDepth int    `json:"modifierDepth,omitempty"`
+}
+
+type legacyAssemblyJSON struct {
+	Code       []AssemblyItem             `json:".code"`
+	Data       map[string]json.RawMessage `json:".data,omitempty"`
+	AuxData    string                     `json:".auxdata,omitempty"`
+	SourceList []string                   `json:"sourceList,omitempty"`
+}
+
+// UnmarshalJSON splits the mixed ".data" section into sub-assemblies and hex data.
+func (a *LegacyAssembly) UnmarshalJSON(data []byte) error {
+	var raw legacyAssemblyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*a = LegacyAssembly{
+		Code:       raw.Code,
+		AuxData:    raw.AuxData,
+		SourceList: raw.SourceList,
+	}
+
+	for key, entry := range raw.Data {
+		var hexData string
+		if err := json.Unmarshal(entry, &hexData); err == nil {
+			if a.Data == nil {
+				a.Data = make(map[string]string)
+			}
+			a.Data[key] = hexData
+			continue
+		}
+
+		sub := &LegacyAssembly{}
+		if err := json.Unmarshal(entry, sub); err != nil {
+			return fmt.Errorf("invalid legacy assembly data entry %s: %w", key, err)
+		}
+		if a.SubAssemblies == nil {
+			a.SubAssemblies = make(map[string]*LegacyAssembly)
+		}
+		a.SubAssemblies[key] = sub
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the assembly in the layout produced by solc.
+func (a LegacyAssembly) MarshalJSON() ([]byte, error) {
+	raw := legacyAssemblyJSON{
+		Code:       a.Code,
+		AuxData:    a.AuxData,
+		SourceList: a.SourceList,
+	}
+
+	if len(a.SubAssemblies)+len(a.Data) > 0 {
+		raw.Data = make(map[string]json.RawMessage, len(a.SubAssemblies)+len(a.Data))
+		for key, hexData := range a.Data {
+			encoded, err := json.Marshal(hexData)
+			if err != nil {
+				return nil, err
+			}
+			raw.Data[key] = encoded
+		}
+		for key, sub := range a.SubAssemblies {
+			encoded, err := json.Marshal(sub)
+			if err != nil {
+				return nil, err
+			}
+			raw.Data[key] = encoded
+		}
+	}
+
+	return json.Marshal(raw)
+}
@@ -0,0 +1,148 @@
+package solc
+
+import (
+	"fmt"
+	"testing"
+
+	"rogchap.com/v8go"
+)
+
+// BenchmarkInitScriptCompile measures parsing and compiling soljson.js, the part
+// of instance creation a V8 startup snapshot or code cache could skip.
+func BenchmarkInitScriptCompile(b *testing.B) {
+	binary, _ := getEmbeddedBinary("0.8.21")
+	for i := 0; i < b.N; i++ {
+		isolate := v8go.NewIsolate()
+		if _, err := isolate.CompileUnboundScript(binary, "soljson.js", v8go.CompileOptions{}); err != nil {
+			b.Fatal(err)
+		}
+		isolate.Dispose()
+	}
+}
+
+// BenchmarkInitScriptRun measures running the compiled soljson.js, which
+// instantiates the embedded WebAssembly module and dominates instance creation.
+func BenchmarkInitScriptRun(b *testing.B) {
+	binary, _ := getEmbeddedBinary("0.8.21")
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		isolate := v8go.NewIsolate()
+		ctx := v8go.NewContext(isolate)
+		script, err := isolate.CompileUnboundScript(binary, "soljson.js", v8go.CompileOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if _, err := script.Run(ctx); err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		ctx.Close()
+		isolate.Dispose()
+		b.StartTimer()
+	}
+}
+
+// benchmarkImportCallback resolves the math and string libraries used by the import benchmarks
+func benchmarkImportCallback(url string) ImportResult {
+	switch url {
+	case "lib/Math.sol":
+		return ImportResult{Contents: mathLibrary}
+	case "lib/String.sol":
+		return ImportResult{Contents: stringLibrary}
+	}
+	return ImportResult{Error: fmt.Sprintf("File not found: %s", url)}
+}
+
+// benchmarkInput returns an input compiling the given contract to abi and bytecode.
+func benchmarkInput(content string) *Input {
+	return &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Contract.sol": {Content: content}},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi", "evm.bytecode.object"}},
+			},
+		},
+	}
+}
+
+func BenchmarkNewWithVersion(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		compiler, err := NewWithVersion("0.8.21")
+		if err != nil {
+			b.Fatal(err)
+		}
+		compiler.Close()
+	}
+}
+
+func BenchmarkCompileSimple(b *testing.B) {
+	compiler, err := NewWithVersion("0.8.21")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer compiler.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := compiler.CompileWithOptions(benchmarkInput(constructorContract), nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompileWithImports(b *testing.B) {
+	compiler, err := NewWithVersion("0.8.21")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer compiler.Close()
+
+	options := &CompileOptions{ImportCallback: benchmarkImportCallback}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := compiler.CompileWithOptions(benchmarkInput(contractWithMultipleImports), options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCompileLargeImport compiles a contract importing a 512 KB library,
+// measuring the cost of passing large import contents to the compiler.
+func BenchmarkCompileLargeImport(b *testing.B) {
+	compiler, err := NewWithVersion("0.8.21")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer compiler.Close()
+
+	library := largeLibrary(512 << 10)
+	options := &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			return ImportResult{Contents: library}
+		},
+	}
+	content := "pragma solidity ^0.8.0;\nimport \"lib/Large.sol\";\ncontract Main {}"
+
+	b.SetBytes(int64(len(library)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := compiler.CompileWithOptions(benchmarkInput(content), options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkResolveImports isolates the import resolution overhead from compilation.
+func BenchmarkResolveImports(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		resolver := newImportResolver(benchmarkImportCallback)
+		if _, err := resolver.resolveImports(benchmarkInput(contractWithMultipleImports)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package solc
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// getOutputCacheDir returns the directory holding cached compilation outputs (~/solc/outputs)
+func getOutputCacheDir() (string, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "outputs"), nil
+}
+
+// CompileCached compiles the input like CompileWithOptions, but first looks
+// for the output in an on-disk cache keyed by the SHA-256 of the compiler
+// version and the marshalled input. Imports are resolved through the
+// options' ImportCallback before hashing, so changes to imported files
+// invalidate the entry too. Outputs are stored gzip-compressed under
+// ~/solc/outputs; use ClearCompileCache to invalidate them.
+//
+// Outputs containing internal compiler errors are not cached. Failing to
+// write the cache is reported on stderr and does not fail the compilation.
+func CompileCached(compiler Solc, input *Input, options *CompileOptions) (*Output, error) {
+	if compiler == nil {
+		return nil, fmt.Errorf("compiler cannot be nil")
+	}
+	if input == nil {
+		return nil, fmt.Errorf("input cannot be nil")
+	}
+
+	// Resolve imports up front on a copy so the key covers their contents
+	if callback := options.importCallback(); callback != nil {
+		resolved, resolver, err := resolveInputCopy(input, callback, options)
+		if err != nil {
+			return nil, err
+		}
+		input = resolved
+
+		withoutCallback := *options
+		withoutCallback.ImportCallback = nil
+		withoutCallback.IncludeConsoleLog = false
+		withoutCallback.IncludePaths = nil
+		options = &withoutCallback
+		defer func() {
+			if options.Stats != nil {
+				options.Stats.ImportsResolved = resolver.callbackCount
+			}
+		}()
+	}
+
+	// Strict and fail-fast modes are applied to cached and fresh outputs alike below
+	strict := options != nil && options.StrictWarnings
+	failFast := options != nil && options.FailFast
+	if strict || failFast {
+		lenient := *options
+		lenient.StrictWarnings = false
+		lenient.FailFast = false
+		options = &lenient
+	}
+
+	key, err := compileCacheKey(compiler.LongVersion(), input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if output, found := loadCachedOutput(key); found {
+		if options != nil && options.Stats != nil {
+			*options.Stats = CompileStats{CacheHit: true}
+		}
+		return output, diagnosticsError(output, strict, failFast)
+	}
+
+	output, err := compiler.CompileWithOptions(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range output.Errors {
+		if e.IsInternal() {
+			return output, diagnosticsError(output, strict, failFast)
+		}
+	}
+	if err := saveOutputToCache(key, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache compilation output: %v\n", err)
+	}
+
+	return output, diagnosticsError(output, strict, failFast)
+}
+
+// ClearCompileCache removes all outputs cached by CompileCached.
+func ClearCompileCache() error {
+	dir, err := getOutputCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear compile cache: %w", err)
+	}
+	return nil
+}
+
+// compileCacheKey hashes the compiler version, the input and the options
+// that change what is sent to the compiler.
+func compileCacheKey(version string, input *Input, options *CompileOptions) (string, error) {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal input: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", version)
+	if options != nil {
+		fmt.Fprintf(h, "stripBOM=%t normalizeLineEndings=%t defaultSPDX=%q noDefaultOutputSelection=%t expandSelectionPatterns=%t\n",
+			options.StripBOM, options.NormalizeLineEndings, options.DefaultSPDX, options.NoDefaultOutputSelection, options.ExpandSelectionPatterns)
+	}
+	h.Write(inputJSON)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadCachedOutput loads an output from the cache, treating unreadable
+// entries as misses.
+func loadCachedOutput(key string) (*Output, bool) {
+	dir, err := getOutputCacheDir()
+	if err != nil {
+		return nil, false
+	}
+
+	file, err := os.Open(filepath.Join(dir, key+".json.gz"))
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+
+	var output Output
+	if err := json.NewDecoder(reader).Decode(&output); err != nil {
+		return nil, false
+	}
+
+	return &output, true
+}
+
+// saveOutputToCache writes an output to the cache, replacing the entry atomically
+func saveOutputToCache(key string, output *Output) error {
+	dir, err := getOutputCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(writer).Encode(output); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, key+".json.gz"))
+}
@@ -0,0 +1,133 @@
+package solc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCached(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	library := mathLibrary
+	newInput := func() *Input {
+		return &Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Calculator.sol": {Content: contractWithImport},
+			},
+			Settings: Settings{
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"abi", "evm.bytecode.object"}},
+				},
+			},
+		}
+	}
+	compile := func() (*Output, CompileStats) {
+		var stats CompileStats
+		output, err := CompileCached(compiler, newInput(), &CompileOptions{
+			ImportCallback: func(url string) ImportResult {
+				return ImportResult{Contents: library}
+			},
+			Stats: &stats,
+		})
+		require.NoError(t, err)
+		return output, stats
+	}
+
+	first, stats := compile()
+	assert.False(t, stats.CacheHit)
+	assert.Equal(t, 1, stats.ImportsResolved)
+	assert.NotEmpty(t, first.Contracts["Calculator.sol"]["Calculator"].EVM.Bytecode.Object)
+
+	second, stats := compile()
+	assert.True(t, stats.CacheHit, "Identical input should hit the cache")
+	firstJSON, err := json.Marshal(first)
+	require.NoError(t, err)
+	secondJSON, err := json.Marshal(second)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(firstJSON), string(secondJSON))
+
+	// Changing an imported file changes the key
+	library = mathLibrary + "\n// changed\n"
+	_, stats = compile()
+	assert.False(t, stats.CacheHit, "Changed import should miss the cache")
+
+	// Corrupt entries are treated as misses
+	dir, err := getOutputCacheDir()
+	require.NoError(t, err)
+	entries, err := filepath.Glob(filepath.Join(dir, "*.json.gz"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		require.NoError(t, os.WriteFile(entry, []byte("corrupt"), 0644))
+	}
+	_, stats = compile()
+	assert.False(t, stats.CacheHit, "Corrupt entry should miss the cache")
+	_, stats = compile()
+	assert.True(t, stats.CacheHit, "Corrupt entry should be replaced")
+
+	// Clearing invalidates everything
+	require.NoError(t, ClearCompileCache())
+	_, stats = compile()
+	assert.False(t, stats.CacheHit, "Cleared cache should miss")
+}
+
+func TestCompileCachedSelectionOptions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	compile := func(input *Input, options CompileOptions) (*Output, CompileStats) {
+		var stats CompileStats
+		options.Stats = &stats
+		output, err := CompileCached(compiler, input, &options)
+		require.NoError(t, err)
+		return output, stats
+	}
+
+	// Without a selection, opting out of the default selection gets its own entry
+	unselected := &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Greeter.sol": {Content: constructorContract}},
+	}
+	output, stats := compile(unselected, CompileOptions{})
+	assert.False(t, stats.CacheHit)
+	assert.NotEmpty(t, output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.Object)
+
+	output, stats = compile(unselected, CompileOptions{NoDefaultOutputSelection: true})
+	assert.False(t, stats.CacheHit, "Opting out of the default selection should miss the cache")
+	assert.Empty(t, output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.Object)
+
+	output, stats = compile(unselected, CompileOptions{})
+	assert.True(t, stats.CacheHit)
+	assert.NotEmpty(t, output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.Object)
+
+	// Pattern selections are sent to solc differently with and without expansion
+	patterned := &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Gen.sol": {Content: generatedContracts}},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"Generated*": []string{"evm.bytecode.object"}},
+			},
+		},
+	}
+	output, stats = compile(patterned, CompileOptions{ExpandSelectionPatterns: true})
+	assert.False(t, stats.CacheHit)
+	assert.NotEmpty(t, output.Contracts["Gen.sol"]["GeneratedA"].EVM.Bytecode.Object)
+
+	output, stats = compile(patterned, CompileOptions{})
+	assert.False(t, stats.CacheHit, "Disabling pattern expansion should miss the cache")
+	assert.Empty(t, output.Contracts["Gen.sol"]["GeneratedA"].EVM.Bytecode.Object)
+}
@@ -0,0 +1,159 @@
+package solc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// combinedField describes a field of the legacy `solc --combined-json` output:
+// the standard JSON output it is selected by and how to read it from a contract.
+type combinedField struct {
+	selection string
+	value     func(c *Contract) any
+}
+
+// combinedFields maps combined-json field names to their standard JSON outputs.
+// The "ast" field is per source and handled separately.
+var combinedFields = map[string]combinedField{
+	"abi": {"abi", func(c *Contract) any {
+		if c.ABI == nil {
+			return []json.RawMessage{}
+		}
+		return c.ABI
+	}},
+	"bin":                       {"evm.bytecode.object", func(c *Contract) any { return c.EVM.Bytecode.Object }},
+	"bin-runtime":               {"evm.deployedBytecode.object", func(c *Contract) any { return c.EVM.DeployedBytecode.Object }},
+	"opcodes":                   {"evm.bytecode.opcodes", func(c *Contract) any { return c.EVM.Bytecode.Opcodes }},
+	"srcmap":                    {"evm.bytecode.sourceMap", func(c *Contract) any { return c.EVM.Bytecode.SourceMap }},
+	"srcmap-runtime":            {"evm.deployedBytecode.sourceMap", func(c *Contract) any { return c.EVM.DeployedBytecode.SourceMap }},
+	"function-debug":            {"evm.bytecode.functionDebugData", func(c *Contract) any { return c.EVM.Bytecode.FunctionDebugData }},
+	"function-debug-runtime":    {"evm.deployedBytecode.functionDebugData", func(c *Contract) any { return c.EVM.DeployedBytecode.FunctionDebugData }},
+	"generated-sources":         {"evm.bytecode.generatedSources", func(c *Contract) any { return c.EVM.Bytecode.GeneratedSources }},
+	"generated-sources-runtime": {"evm.deployedBytecode.generatedSources", func(c *Contract) any { return c.EVM.DeployedBytecode.GeneratedSources }},
+	"asm":                       {"evm.legacyAssembly", func(c *Contract) any { return c.EVM.LegacyAssembly }},
+	"storage-layout":            {"storageLayout", func(c *Contract) any { return c.StorageLayout }},
+	"metadata":                  {"metadata", func(c *Contract) any { return c.Metadata }},
+	"userdoc":                   {"userdoc", func(c *Contract) any { return c.UserDoc }},
+	"devdoc":                    {"devdoc", func(c *Contract) any { return c.DevDoc }},
+	"hashes": {"evm.methodIdentifiers", func(c *Contract) any {
+		if c.EVM.MethodIdentifiers == nil {
+			return map[string]string{}
+		}
+		return c.EVM.MethodIdentifiers
+	}},
+}
+
+// combinedOutput is the structure of `solc --combined-json`.
+type combinedOutput struct {
+	Contracts  map[string]map[string]any `json:"contracts"`
+	SourceList []string                  `json:"sourceList"`
+	Sources    map[string]combinedSource `json:"sources,omitempty"`
+	Version    string                    `json:"version"`
+}
+
+// combinedSource is a source entry of the combined-json output.
+type combinedSource struct {
+	AST json.RawMessage `json:"AST,omitempty"`
+}
+
+// CompileCombinedJSON compiles the input and returns the output in the shape
+// of `solc --combined-json`, for tools that only accept that format. fields
+// are combined-json field names such as "abi", "bin", "bin-runtime",
+// "hashes" or "ast"; they replace the output selection of the input. Like
+// solc, it fails if the compiler reports errors, returning a
+// DiagnosticsError that wraps ErrCompilationFailed.
+func CompileCombinedJSON(compiler Solc, input *Input, fields []string, options *CompileOptions) (string, error) {
+	if compiler == nil {
+		return "", fmt.Errorf("compiler cannot be nil")
+	}
+	if input == nil {
+		return "", fmt.Errorf("input cannot be nil")
+	}
+
+	var selections []string
+	contractSelection := map[string][]string{}
+	for _, field := range fields {
+		if field == "ast" {
+			contractSelection[""] = []string{"ast"}
+			continue
+		}
+		f, ok := combinedFields[field]
+		if !ok {
+			return "", fmt.Errorf("unknown combined-json field %q", field)
+		}
+		selections = append(selections, f.selection)
+	}
+	if len(selections) > 0 {
+		contractSelection["*"] = selections
+	}
+	if len(contractSelection) == 0 {
+		return "", fmt.Errorf("no combined-json fields requested")
+	}
+
+	selected := input.Clone()
+	selected.Settings.OutputSelection = map[string]map[string][]string{"*": contractSelection}
+	output, err := compiler.CompileWithOptions(selected, options)
+	if err != nil {
+		return "", err
+	}
+	if output.HasErrors() {
+		return "", strictDiagnostics(output)
+	}
+
+	return output.CombinedJSON(fields, compiler.LongVersion())
+}
+
+// CombinedJSON converts the output into the shape of `solc --combined-json`
+// with the given fields, naming the compiler version in its "version" field.
+// Contracts are keyed by "file:Name". Fields missing from the output are
+// emitted as empty values, so the output selection should cover them.
+func (o *Output) CombinedJSON(fields []string, version string) (string, error) {
+	combined := combinedOutput{
+		Contracts:  map[string]map[string]any{},
+		SourceList: []string{},
+		Version:    version,
+	}
+
+	withAST := false
+	var contractFields []string
+	for _, field := range fields {
+		if field == "ast" {
+			withAST = true
+			continue
+		}
+		if _, ok := combinedFields[field]; !ok {
+			return "", fmt.Errorf("unknown combined-json field %q", field)
+		}
+		contractFields = append(contractFields, field)
+	}
+
+	o.Range(func(file, name string, c *Contract) bool {
+		values := make(map[string]any, len(contractFields))
+		for _, field := range contractFields {
+			values[field] = combinedFields[field].value(c)
+		}
+		combined.Contracts[file+":"+name] = values
+		return true
+	})
+
+	// The source list is ordered by source ID, which source maps refer to
+	for file := range o.Sources {
+		combined.SourceList = append(combined.SourceList, file)
+	}
+	sort.Slice(combined.SourceList, func(i, j int) bool {
+		return o.Sources[combined.SourceList[i]].ID < o.Sources[combined.SourceList[j]].ID
+	})
+	if withAST {
+		combined.Sources = make(map[string]combinedSource, len(o.Sources))
+		for file, source := range o.Sources {
+			combined.Sources[file] = combinedSource{AST: source.AST}
+		}
+	}
+
+	data, err := json.Marshal(combined)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal combined-json output: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,61 @@
+package solc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCombinedJSON(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+			"Shop.sol":    {Content: externalLibraryContract},
+		},
+	}
+	combinedJSON, err := CompileCombinedJSON(compiler, input, []string{"abi", "bin", "bin-runtime", "hashes", "ast"}, nil)
+	require.NoError(t, err)
+
+	var combined struct {
+		Contracts map[string]struct {
+			ABI        []map[string]any  `json:"abi"`
+			Bin        string            `json:"bin"`
+			BinRuntime string            `json:"bin-runtime"`
+			Hashes     map[string]string `json:"hashes"`
+		} `json:"contracts"`
+		SourceList []string                   `json:"sourceList"`
+		Sources    map[string]json.RawMessage `json:"sources"`
+		Version    string                     `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(combinedJSON), &combined))
+
+	assert.Len(t, combined.Contracts, 5)
+	greeter := combined.Contracts["Greeter.sol:Greeter"]
+	assert.NotEmpty(t, greeter.ABI)
+	assert.NotEmpty(t, greeter.Bin)
+	assert.NotEmpty(t, greeter.BinRuntime)
+	assert.Equal(t, "06661abd", greeter.Hashes["count()"])
+	assert.Contains(t, combined.Contracts, "Shop.sol:Pricing")
+	assert.Equal(t, []string{"Greeter.sol", "Shop.sol"}, combined.SourceList)
+	assert.Contains(t, string(combined.Sources["Shop.sol"]), `"AST"`)
+	assert.Equal(t, compiler.LongVersion(), combined.Version)
+
+	// The caller's output selection is left alone
+	assert.Nil(t, input.Settings.OutputSelection)
+
+	_, err = CompileCombinedJSON(compiler, input, []string{"bin", "gas"}, nil)
+	assert.ErrorContains(t, err, `unknown combined-json field "gas"`)
+
+	_, err = CompileCombinedJSON(compiler, &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Broken.sol": {Content: "contract {"}},
+	}, []string{"bin"}, nil)
+	assert.ErrorIs(t, err, ErrCompilationFailed)
+}
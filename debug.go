@@ -0,0 +1,29 @@
+package solc
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// debugEnabled gates diagnostic output. It starts out enabled only when the
+// SOLC_DEBUG environment variable is "1".
+var debugEnabled atomic.Bool
+
+func init() {
+	debugEnabled.Store(os.Getenv("SOLC_DEBUG") == "1")
+}
+
+// SetDebug enables or disables diagnostic output on stdout, such as the
+// messages logged by the JavaScript side through debugLog. It is off by
+// default unless SOLC_DEBUG=1 is set, and is safe to call concurrently.
+func SetDebug(enabled bool) {
+	debugEnabled.Store(enabled)
+}
+
+// debugf prints a diagnostic message if debug output is enabled.
+func debugf(format string, args ...interface{}) {
+	if debugEnabled.Load() {
+		fmt.Printf(format, args...)
+	}
+}
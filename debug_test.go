@@ -0,0 +1,51 @@
+package solc
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"rogchap.com/v8go"
+)
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestSetDebug(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	enabled := debugEnabled.Load()
+	defer SetDebug(enabled)
+
+	logFromJS := func() {
+		err := compiler.WithContext(func(_ *v8go.Isolate, ctx *v8go.Context) error {
+			_, err := ctx.RunScript(`debugLog("hello from js")`, "debug.js")
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	SetDebug(false)
+	assert.Empty(t, captureStdout(t, logFromJS), "Debug output should be silenced")
+
+	SetDebug(true)
+	assert.Equal(t, "JS DEBUG: hello from js\n", captureStdout(t, logFromJS))
+}
@@ -0,0 +1,245 @@
+package solc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OutputDiff lists the differences between two compilation outputs, with
+// contracts identified as "file:contract".
+type OutputDiff struct {
+	// Added are contracts only present in the second output.
+	Added []string
+	// Removed are contracts only present in the first output.
+	Removed []string
+	// Changed are contracts present in both outputs that differ, sorted by name.
+	Changed []ContractDiff
+}
+
+// IsEmpty reports whether the outputs have no differences.
+func (d *OutputDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// ContractDiff lists the differences of a contract between two outputs.
+type ContractDiff struct {
+	Name string
+	// ABIAdded and ABIRemoved are entry signatures such as
+	// "function transfer(address,uint256) nonpayable".
+	ABIAdded   []string
+	ABIRemoved []string
+	// BytecodeChanged and DeployedBytecodeChanged compare the bytecode
+	// objects with the trailing CBOR metadata stripped, since it embeds the
+	// settings and source hashes.
+	BytecodeChanged         bool
+	DeployedBytecodeChanged bool
+	// StorageChanges are state variables whose slot, offset or type changed,
+	// or that were added or removed.
+	StorageChanges []StorageChange
+}
+
+// StorageChange is a difference in the storage location of a state variable.
+// Before is nil for added variables and After is nil for removed ones.
+type StorageChange struct {
+	Label  string
+	Before *StorageLocation
+	After  *StorageLocation
+}
+
+// StorageLocation is where a state variable is stored.
+type StorageLocation struct {
+	Slot   string
+	Offset int
+	// Type is the label of the type, e.g. "mapping(address => uint256)".
+	Type string
+}
+
+// DiffOutputs compares two outputs contract by contract, reporting changes
+// in the ABI, the bytecode and the storage layout. Only outputs that were
+// selected in both compilations can be compared meaningfully; storage layout
+// changes are only reported when both outputs include storageLayout.
+func DiffOutputs(a, b *Output) (*OutputDiff, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("outputs cannot be nil")
+	}
+
+	before, after := outputContracts(a), outputContracts(b)
+	diff := &OutputDiff{}
+
+	for _, name := range sortedContractNames(after) {
+		if _, exists := before[name]; !exists {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+
+	for _, name := range sortedContractNames(before) {
+		afterContract, exists := after[name]
+		if !exists {
+			diff.Removed = append(diff.Removed, name)
+			continue
+		}
+
+		contractDiff, err := diffContract(name, before[name], afterContract)
+		if err != nil {
+			return nil, err
+		}
+		if contractDiff != nil {
+			diff.Changed = append(diff.Changed, *contractDiff)
+		}
+	}
+
+	return diff, nil
+}
+
+// outputContracts flattens the contracts of an output by "file:contract".
+func outputContracts(output *Output) map[string]*Contract {
+	contracts := make(map[string]*Contract)
+	for file, fileContracts := range output.Contracts {
+		for name := range fileContracts {
+			contract := fileContracts[name]
+			contracts[file+":"+name] = &contract
+		}
+	}
+	return contracts
+}
+
+// sortedContractNames returns the sorted keys of contracts.
+func sortedContractNames(contracts map[string]*Contract) []string {
+	names := make([]string, 0, len(contracts))
+	for name := range contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffContract compares a contract between two outputs, returning nil if
+// there are no differences.
+func diffContract(name string, a, b *Contract) (*ContractDiff, error) {
+	diff := &ContractDiff{Name: name}
+
+	abiA, err := abiSignatures(a)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	abiB, err := abiSignatures(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	diff.ABIAdded = setDifference(abiB, abiA)
+	diff.ABIRemoved = setDifference(abiA, abiB)
+
+	diff.BytecodeChanged = stripMetadata(a.EVM.Bytecode.Object) != stripMetadata(b.EVM.Bytecode.Object)
+	diff.DeployedBytecodeChanged = stripMetadata(a.EVM.DeployedBytecode.Object) != stripMetadata(b.EVM.DeployedBytecode.Object)
+
+	if a.StorageLayout != nil && b.StorageLayout != nil {
+		diff.StorageChanges = diffStorage(a.StorageLayout, b.StorageLayout)
+	}
+
+	if len(diff.ABIAdded) == 0 && len(diff.ABIRemoved) == 0 && !diff.BytecodeChanged &&
+		!diff.DeployedBytecodeChanged && len(diff.StorageChanges) == 0 {
+		return nil, nil
+	}
+	return diff, nil
+}
+
+// abiSignatures returns a signature for every ABI entry of the contract.
+func abiSignatures(contract *Contract) (map[string]bool, error) {
+	entries, err := contract.ParsedABI()
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		signature := entry.Type
+		if entry.Type == "function" || entry.Type == "event" || entry.Type == "error" {
+			signature += " " + entry.Name
+		}
+		signature += "(" + canonicalTypes(entry.Inputs) + ")"
+		if len(entry.Outputs) > 0 {
+			signature += " returns (" + canonicalTypes(entry.Outputs) + ")"
+		}
+		if entry.StateMutability != "" {
+			signature += " " + entry.StateMutability
+		}
+		signatures[signature] = true
+	}
+	return signatures, nil
+}
+
+// setDifference returns the sorted elements of a that are not in b.
+func setDifference(a, b map[string]bool) []string {
+	var diff []string
+	for element := range a {
+		if !b[element] {
+			diff = append(diff, element)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// diffStorage compares two storage layouts by variable label.
+func diffStorage(a, b *StorageLayout) []StorageChange {
+	before, after := storageLocations(a), storageLocations(b)
+
+	labels := make(map[string]bool)
+	for label := range before {
+		labels[label] = true
+	}
+	for label := range after {
+		labels[label] = true
+	}
+
+	var changes []StorageChange
+	for _, label := range setDifference(labels, nil) {
+		locationA, locationB := before[label], after[label]
+		if locationA != nil && locationB != nil && *locationA == *locationB {
+			continue
+		}
+		changes = append(changes, StorageChange{Label: label, Before: locationA, After: locationB})
+	}
+	return changes
+}
+
+// storageLocations maps the variables of a storage layout to their locations.
+// Variables of base contracts are qualified by the contract when the label
+// is shadowed.
+func storageLocations(layout *StorageLayout) map[string]*StorageLocation {
+	locations := make(map[string]*StorageLocation, len(layout.Storage))
+	for _, entry := range layout.Storage {
+		label := entry.Label
+		if _, exists := locations[label]; exists {
+			label = entry.Contract + "." + entry.Label
+		}
+
+		typeLabel := entry.Type
+		if storageType, exists := layout.Types[entry.Type]; exists {
+			typeLabel = storageType.Label
+		}
+		locations[label] = &StorageLocation{Slot: entry.Slot, Offset: entry.Offset, Type: typeLabel}
+	}
+	return locations
+}
+
+// stripMetadata removes the CBOR-encoded metadata that solc appends to the
+// bytecode, whose length is given by the last two bytes. The object is
+// returned unchanged if it does not end with metadata.
+func stripMetadata(object string) string {
+	object = strings.TrimPrefix(object, "0x")
+	code, err := hex.DecodeString(object)
+	if err != nil || len(code) < 2 {
+		return object
+	}
+
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - length
+	// The metadata is a CBOR map, whose major type is 5 (0xa0 to 0xbf)
+	if length == 0 || start < 0 || code[start]&0xe0 != 0xa0 {
+		return object
+	}
+	return object[:start*2]
+}
@@ -0,0 +1,110 @@
+package solc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vaultV1 = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Vault {
+    address public owner;
+    mapping(address => uint256) public balances;
+
+    function deposit() public payable {
+        balances[msg.sender] += msg.value;
+    }
+}
+`
+
+const vaultV2 = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Vault {
+    address public owner;
+    bool public paused;
+    uint256 public total;
+    mapping(address => uint256) public balances;
+
+    function deposit() public payable {
+        require(!paused);
+        balances[msg.sender] += msg.value;
+        total += msg.value;
+    }
+}
+`
+
+func compileVault(t *testing.T, source string, optimize bool) *Output {
+	t.Helper()
+
+	output := compileInput(t, "0.8.21", &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Vault.sol": {Content: source},
+		},
+		Settings: Settings{
+			Optimizer: Optimizer{Enabled: optimize, Runs: 200},
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi", "storageLayout", "evm.bytecode.object", "evm.deployedBytecode.object"}},
+			},
+		},
+	})
+	require.False(t, output.HasErrors(), output.Errors)
+	return output
+}
+
+func TestDiffOutputsOptimizer(t *testing.T) {
+	unoptimized := compileVault(t, vaultV1, false)
+	optimized := compileVault(t, vaultV1, true)
+
+	diff, err := DiffOutputs(unoptimized, optimized)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+	require.Len(t, diff.Changed, 1)
+
+	vault := diff.Changed[0]
+	assert.Equal(t, "Vault.sol:Vault", vault.Name)
+	assert.True(t, vault.BytecodeChanged)
+	assert.True(t, vault.DeployedBytecodeChanged)
+	assert.Empty(t, vault.ABIAdded, "Optimizer should not change the ABI")
+	assert.Empty(t, vault.ABIRemoved)
+	assert.Empty(t, vault.StorageChanges, "Optimizer should not change the storage layout")
+
+	// Identical compilations have no differences
+	diff, err = DiffOutputs(unoptimized, compileVault(t, vaultV1, false))
+	require.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+}
+
+func TestDiffOutputsStorageLayout(t *testing.T) {
+	diff, err := DiffOutputs(compileVault(t, vaultV1, false), compileVault(t, vaultV2, false))
+	require.NoError(t, err)
+	require.Len(t, diff.Changed, 1)
+
+	vault := diff.Changed[0]
+	assert.ElementsMatch(t, []string{
+		"function paused() returns (bool) view",
+		"function total() returns (uint256) view",
+	}, vault.ABIAdded)
+	assert.Empty(t, vault.ABIRemoved)
+
+	changes := make(map[string]StorageChange)
+	for _, change := range vault.StorageChanges {
+		changes[change.Label] = change
+	}
+	require.Len(t, changes, 3)
+
+	assert.Nil(t, changes["paused"].Before, "paused should be added")
+	assert.Equal(t, &StorageLocation{Slot: "0", Offset: 20, Type: "bool"}, changes["paused"].After)
+	assert.Nil(t, changes["total"].Before, "total should be added")
+	assert.Equal(t, "1", changes["total"].After.Slot)
+	assert.Equal(t, "1", changes["balances"].Before.Slot, "balances should move")
+	assert.Equal(t, "2", changes["balances"].After.Slot)
+	assert.Equal(t, "mapping(address => uint256)", changes["balances"].After.Type)
+}
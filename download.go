@@ -1,17 +1,107 @@
 package solc
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
 const SOLC_BINARIES_BASE_URL = "https://binaries.soliditylang.org/bin"
 
+// SOLC_BIN_GITHUB_URL serves the same files as SOLC_BINARIES_BASE_URL from
+// the ethereum/solc-bin repository, and is the default download mirror.
+const SOLC_BIN_GITHUB_URL = "https://raw.githubusercontent.com/ethereum/solc-bin/gh-pages/bin"
+
+// binariesBaseURL is the base URL used for downloads, overridable in tests
+var binariesBaseURL = SOLC_BINARIES_BASE_URL
+
+// downloadMirrors are the base URLs tried in order when a download from
+// binariesBaseURL fails
+var (
+	downloadMirrorsMu sync.RWMutex
+	downloadMirrors   = []string{SOLC_BIN_GITHUB_URL}
+)
+
+// SetDownloadMirrors sets the base URLs that are tried in order when fetching
+// the version list or a compiler binary from the primary host fails. Each
+// mirror must serve list.json and the soljson files under the same names as
+// SOLC_BINARIES_BASE_URL. The default is SOLC_BIN_GITHUB_URL; call it without
+// arguments to disable the fallback.
+func SetDownloadMirrors(urls ...string) {
+	downloadMirrorsMu.Lock()
+	defer downloadMirrorsMu.Unlock()
+	downloadMirrors = append([]string(nil), urls...)
+}
+
+// downloadBaseURLs returns the primary base URL followed by the mirrors.
+func downloadBaseURLs() []string {
+	downloadMirrorsMu.RLock()
+	defer downloadMirrorsMu.RUnlock()
+	return append([]string{binariesBaseURL}, downloadMirrors...)
+}
+
+// versionListTTL is how long a fetched version list is reused in-process
+const versionListTTL = time.Hour
+
+// versionListCache memoizes the version list across all compiler instances
+var versionListCache struct {
+	mu        sync.Mutex
+	list      *VersionList
+	fetchedAt time.Time
+}
+
+// httpClient is used for all downloads. It defaults to http.DefaultClient,
+// which honors the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+var (
+	httpClientMu sync.RWMutex
+	httpClient   = http.DefaultClient
+)
+
+// SetHTTPClient sets the client used to download the version list and
+// compiler binaries, e.g. to configure timeouts or a custom transport. Pass
+// nil to restore http.DefaultClient.
+func SetHTTPClient(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+	httpClient = client
+}
+
+// SetTLSConfig makes downloads use the given TLS configuration, e.g. with a
+// RootCAs pool that trusts the certificate of a TLS-intercepting proxy. The
+// proxy environment variables are still honored. Pass nil to restore the
+// default client.
+func SetTLSConfig(config *tls.Config) {
+	if config == nil {
+		SetHTTPClient(nil)
+		return
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	SetHTTPClient(&http.Client{Transport: transport})
+}
+
+// getHTTPClient returns the client used for downloads.
+func getHTTPClient() *http.Client {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	return httpClient
+}
+
 // getCacheDir returns the cache directory path (~/.solc)
 func getCacheDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -40,7 +130,9 @@ func ensureCacheDir(version string) error {
 	return os.MkdirAll(versionDir, 0755)
 }
 
-// loadCachedBinary loads a binary from cache if it exists
+// loadCachedBinary loads a binary from cache if it exists. Binaries that
+// fail validation, e.g. because an earlier download was truncated, are
+// treated as missing so that they are downloaded again.
 func loadCachedBinary(version string) (string, bool) {
 	cachePath, err := getCachedBinaryPath(version)
 	if err != nil {
@@ -51,10 +143,95 @@ func loadCachedBinary(version string) (string, bool) {
 	if err != nil {
 		return "", false
 	}
+	if err := validateBinary(string(content)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring cached binary for version %s: %v\n", version, err)
+		return "", false
+	}
+
+	return string(content), true
+}
+
+// validateBinary performs a cheap sanity check that content is an emscripten
+// soljson.js build, which always defines the Module object.
+func validateBinary(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("binary is empty")
+	}
+	if !strings.Contains(content, "Module") {
+		return fmt.Errorf("binary does not look like a soljson.js build")
+	}
+	return nil
+}
+
+// getChecksummedBinaryPath returns the path of a binary in the
+// content-addressed cache, keyed by its sha256 checksum.
+func getChecksummedBinaryPath(checksum string) (string, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "sha256", checksum+".js"), nil
+}
+
+// loadChecksummedBinary loads a binary from the content-addressed cache. A
+// file whose content no longer matches its checksum is treated as missing.
+func loadChecksummedBinary(checksum string) (string, bool) {
+	cachePath, err := getChecksummedBinaryPath(checksum)
+	if err != nil {
+		return "", false
+	}
+
+	content, err := os.ReadFile(cachePath)
+	if err != nil {
+		return "", false
+	}
+	if err := verifyChecksum(string(content), checksum); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring cached binary %s: %v\n", cachePath, err)
+		return "", false
+	}
 
 	return string(content), true
 }
 
+// saveChecksummedBinary saves a binary to the content-addressed cache.
+func saveChecksummedBinary(checksum string, content string) error {
+	cachePath, err := getChecksummedBinaryPath(checksum)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath, []byte(content), 0644)
+}
+
+// verifyChecksum checks content against a hex sha256 checksum.
+func verifyChecksum(content, checksum string) error {
+	sum := sha256.Sum256([]byte(content))
+	if actual := hex.EncodeToString(sum[:]); actual != checksum {
+		return fmt.Errorf("sha256 checksum mismatch: expected %s, got %s", checksum, actual)
+	}
+	return nil
+}
+
+// expectedChecksum returns the lower-case hex sha256 checksum that the
+// memoized version list records for a binary file, or "" if the list has not
+// been fetched or does not list the file. It never fetches the list.
+func expectedChecksum(filename string) string {
+	versionListCache.mu.Lock()
+	defer versionListCache.mu.Unlock()
+
+	if versionListCache.list == nil {
+		return ""
+	}
+	for _, build := range versionListCache.list.Builds {
+		if build.Path == filename {
+			return strings.TrimPrefix(strings.ToLower(build.SHA256), "0x")
+		}
+	}
+	return ""
+}
+
 // saveBinaryToCache saves a binary to the cache
 func saveBinaryToCache(version string, content string) error {
 	if err := ensureCacheDir(version); err != nil {
@@ -83,94 +260,215 @@ type Build struct {
 	SHA256      string `json:"sha256"`
 }
 
+// fetchVersionList fetches the version list from the primary host, falling
+// back to the mirrors in order.
 func fetchVersionList() (*VersionList, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/list.json", SOLC_BINARIES_BASE_URL))
+	var errs []error
+	for _, baseURL := range downloadBaseURLs() {
+		versionList, err := fetchVersionListFrom(baseURL)
+		if err == nil {
+			return versionList, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// fetchVersionListFrom fetches the version list from a single host.
+func fetchVersionListFrom(baseURL string) (*VersionList, error) {
+	url := fmt.Sprintf("%s/list.json", baseURL)
+	resp, err := getHTTPClient().Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch version list: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch version list: HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch version list from %s: HTTP %d", url, resp.StatusCode)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read version list response: %w", err)
+		return nil, fmt.Errorf("failed to read version list response from %s after %d bytes: %w", url, len(body), err)
 	}
 
 	var versionList VersionList
 	if err := json.Unmarshal(body, &versionList); err != nil {
-		return nil, fmt.Errorf("failed to parse version list: %w", err)
+		return nil, fmt.Errorf("failed to parse version list from %s: %w", url, err)
 	}
 
 	return &versionList, nil
 }
 
-func resolveVersion(version string) (string, error) {
+// getVersionList returns the memoized version list, fetching it when it is
+// missing or older than versionListTTL. Concurrent callers share a single fetch.
+func getVersionList() (*VersionList, error) {
+	versionListCache.mu.Lock()
+	defer versionListCache.mu.Unlock()
+
+	if versionListCache.list != nil && time.Since(versionListCache.fetchedAt) < versionListTTL {
+		return versionListCache.list, nil
+	}
+
 	versionList, err := fetchVersionList()
+	if err != nil {
+		return nil, err
+	}
+
+	versionListCache.list = versionList
+	versionListCache.fetchedAt = time.Now()
+	return versionList, nil
+}
+
+func resolveVersion(version string) (string, error) {
+	versionList, err := getVersionList()
 	if err != nil {
 		return "", err
 	}
 
 	filename, exists := versionList.Releases[version]
 	if !exists {
-		return "", fmt.Errorf("version %s not found", version)
+		return "", fmt.Errorf("%w: %s", ErrVersionNotFound, version)
 	}
 
 	return filename, nil
 }
 
+// downloadSolcBinary returns the binary file of a version, from the cache if
+// possible. When the version list records the sha256 checksum of the file,
+// the cache is keyed by that checksum instead of the version, so every
+// version resolving to the same file shares an entry, and both cached and
+// downloaded binaries are verified against it. A binary in the version cache,
+// e.g. from an earlier release of this package, is still used if it matches
+// the checksum, and copied to the checksum cache. Downloads that do
+// not match are rejected and the next host is tried.
 func downloadSolcBinary(version, filename string) (string, error) {
+	checksum := expectedChecksum(filename)
+
 	// First check if we have it cached
+	if checksum != "" {
+		if content, found := loadChecksummedBinary(checksum); found {
+			return content, nil
+		}
+	}
 	if content, found := loadCachedBinary(version); found {
+		if checksum == "" {
+			return content, nil
+		}
+		if err := verifyChecksum(content, checksum); err == nil {
+			if err := saveChecksummedBinary(checksum, content); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to cache binary for version %s: %v\n", version, err)
+			}
+			return content, nil
+		}
+	}
+
+	// Download from remote, falling back to the mirrors in order
+	var errs []error
+	for _, baseURL := range downloadBaseURLs() {
+		content, err := downloadSolcBinaryFrom(baseURL, filename)
+		if err == nil && checksum != "" {
+			if err = verifyChecksum(content, checksum); err != nil {
+				err = fmt.Errorf("invalid solc binary %s from %s/%s: %w", filename, baseURL, filename, err)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Save to cache for future use
+		if checksum != "" {
+			err = saveChecksummedBinary(checksum, content)
+		} else {
+			err = saveBinaryToCache(version, content)
+		}
+		if err != nil {
+			// Log the error but don't fail the download
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache binary for version %s: %v\n", version, err)
+		}
 		return content, nil
 	}
+	return "", errors.Join(errs...)
+}
 
-	// Download from remote
-	url := fmt.Sprintf("%s/%s", SOLC_BINARIES_BASE_URL, filename)
-	resp, err := http.Get(url)
+// downloadSolcBinaryFrom downloads a compiler binary from a single host.
+func downloadSolcBinaryFrom(baseURL, filename string) (string, error) {
+	url := fmt.Sprintf("%s/%s", baseURL, filename)
+	resp, err := getHTTPClient().Get(url)
 	if err != nil {
 		return "", fmt.Errorf("failed to download solc binary: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download solc binary: HTTP %d", resp.StatusCode)
+		return "", fmt.Errorf("failed to download solc binary %s from %s: HTTP %d", filename, url, resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read solc binary: %w", err)
+		return "", fmt.Errorf("failed to read solc binary %s from %s after %d bytes: %w", filename, url, len(body), err)
 	}
 
-	content := string(body)
+	return string(body), nil
+}
 
-	// Save to cache for future use
-	if err := saveBinaryToCache(version, content); err != nil {
-		// Log the error but don't fail the download
-		fmt.Fprintf(os.Stderr, "Warning: failed to cache binary for version %s: %v\n", version, err)
+func NewWithVersion(version string) (Solc, error) {
+	return NewWithVersionOptions(version, nil)
+}
+
+// NewWithVersionContext creates a compiler for the given version like
+// NewWithVersion and closes it automatically once ctx is done, tying the
+// lifetime of the V8 isolate to e.g. a request context. A compilation running
+// when the context is cancelled finishes before the compiler is closed;
+// later calls fail with a closed error. Closing the compiler earlier is
+// still allowed and releases the context.
+func NewWithVersionContext(ctx context.Context, version string) (Solc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	compiler, err := NewWithVersion(version)
+	if err != nil {
+		return nil, err
 	}
 
-	return content, nil
+	base := compiler.(*baseSolc)
+	base.mu.Lock()
+	base.stopCloseOnDone = context.AfterFunc(ctx, func() {
+		base.Close()
+	})
+	base.mu.Unlock()
+
+	return compiler, nil
 }
 
-func NewWithVersion(version string) (Solc, error) {
-	// First, check if we have an embedded binary for this version
+// NewWithVersionOptions creates a compiler for the given version like
+// NewWithVersion, configured by options. Pass nil for options to use the defaults.
+func NewWithVersionOptions(version string, options *InstanceOptions) (Solc, error) {
+	// Binaries registered by the caller, in this or an earlier process, take precedence
+	if binaryContent, exists := loadRegisteredBinary(version); exists {
+		return NewWithOptions(binaryContent, options)
+	}
+
+	// Then, check if we have an embedded binary for this version
 	if binaryContent, exists := getEmbeddedBinary(version); exists {
-		return New(binaryContent)
+		return NewWithOptions(binaryContent, options)
 	}
 
 	// Fall back to downloading from remote if not embedded
 	filename, err := resolveVersion(version)
+	if errors.Is(err, ErrVersionNotFound) {
+		return nil, &ResolveError{Version: version, Err: err}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve version %s: %w", version, err)
+		return nil, &DownloadError{Version: version, Err: err}
 	}
 
 	binaryContent, err := downloadSolcBinary(version, filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download solc binary for version %s: %w", version, err)
+		return nil, &DownloadError{Version: version, Err: err}
 	}
 
-	return New(binaryContent)
+	return NewWithOptions(binaryContent, options)
 }
@@ -0,0 +1,373 @@
+package solc
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBinariesServer serves a fake binaries host with the given releases
+// (version -> filename) and files (filename -> content), and points downloads at
+// it for the duration of the test. The returned counter tracks list.json requests.
+func newTestBinariesServer(t *testing.T, releases, files map[string]string) *atomic.Int32 {
+	t.Helper()
+
+	_, listRequests := startTestBinariesServer(t, releases, files, false)
+	return listRequests
+}
+
+// startTestBinariesServer is like newTestBinariesServer, optionally serving
+// over TLS with a certificate from the server's own test CA.
+func startTestBinariesServer(t *testing.T, releases, files map[string]string, useTLS bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var listRequests atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list.json" {
+			listRequests.Add(1)
+			json.NewEncoder(w).Encode(VersionList{Releases: releases})
+			return
+		}
+		content, ok := files[r.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	if useTLS {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+
+	originalURL := binariesBaseURL
+	binariesBaseURL = server.URL
+	SetDownloadMirrors()
+	resetVersionListCache()
+	t.Cleanup(func() {
+		binariesBaseURL = originalURL
+		SetDownloadMirrors(SOLC_BIN_GITHUB_URL)
+		resetVersionListCache()
+	})
+
+	return server, &listRequests
+}
+
+// resetVersionListCache drops the memoized version list.
+func resetVersionListCache() {
+	versionListCache.mu.Lock()
+	defer versionListCache.mu.Unlock()
+	versionListCache.list = nil
+	versionListCache.fetchedAt = time.Time{}
+}
+
+func TestVersionListMemoization(t *testing.T) {
+	listRequests := newTestBinariesServer(t,
+		map[string]string{"0.7.6": "soljson-v0.7.6.js"},
+		map[string]string{"soljson-v0.7.6.js": "Module"},
+	)
+
+	// Concurrent lookups share a single fetch
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			filename, err := resolveVersion("0.7.6")
+			assert.NoError(t, err)
+			assert.Equal(t, "soljson-v0.7.6.js", filename)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), listRequests.Load(), "Version list should be fetched once")
+
+	// An expired list is fetched again
+	versionListCache.mu.Lock()
+	versionListCache.fetchedAt = time.Now().Add(-2 * versionListTTL)
+	versionListCache.mu.Unlock()
+
+	_, err := resolveVersion("0.7.6")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), listRequests.Load(), "Expired version list should be refetched")
+}
+
+func TestUnknownVersionNotFound(t *testing.T) {
+	listRequests := newTestBinariesServer(t,
+		map[string]string{"0.7.6": "soljson-v0.7.6.js"},
+		map[string]string{"soljson-v0.7.6.js": "Module"},
+	)
+
+	// Repeated unknown lookups are answered from the memoized list
+	for i := 0; i < 5; i++ {
+		_, err := resolveVersion("0.0.0-unknown")
+		assert.ErrorIs(t, err, ErrVersionNotFound)
+	}
+	assert.Equal(t, int32(1), listRequests.Load(), "Unknown versions should not refetch the version list")
+
+	_, err := NewWithVersion("0.0.0-unknown")
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+	assert.Equal(t, int32(1), listRequests.Load())
+
+	_, err = VersionForPragma("^0.9.0")
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+}
+
+func TestSetTLSConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server, _ := startTestBinariesServer(t, map[string]string{
+		"0.0.1": "soljson-v0.0.1.js",
+	}, map[string]string{
+		"soljson-v0.0.1.js": "// fake binary",
+	}, true)
+	t.Cleanup(func() { SetHTTPClient(nil) })
+
+	// The default pool does not trust the test CA
+	_, err := fetchVersionList()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "certificate")
+
+	// Trusting the CA of the server makes downloads work
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	SetTLSConfig(&tls.Config{RootCAs: pool})
+
+	filename, err := resolveVersion("0.0.1")
+	require.NoError(t, err)
+	content, err := downloadSolcBinary("0.0.1", filename)
+	require.NoError(t, err)
+	assert.Equal(t, "// fake binary", content)
+
+	// A custom client works the same way
+	SetHTTPClient(server.Client())
+	_, err = fetchVersionList()
+	assert.NoError(t, err)
+
+	// Restoring the default client rejects the CA again
+	SetTLSConfig(nil)
+	_, err = fetchVersionList()
+	assert.Error(t, err)
+}
+
+func TestDownloadErrorContext(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	newTestBinariesServer(t, map[string]string{
+		"0.0.1": "soljson-v0.0.1+commit.unexpected.js",
+	}, nil)
+
+	_, err := NewWithVersion("0.0.1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "0.0.1")
+	assert.Contains(t, err.Error(), "soljson-v0.0.1+commit.unexpected.js", "Error should name the resolved filename")
+	assert.Contains(t, err.Error(), binariesBaseURL+"/soljson-v0.0.1+commit.unexpected.js", "Error should include the attempted URL")
+	assert.Contains(t, err.Error(), "HTTP 404")
+}
+
+func TestErrorKinds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server, _ := startTestBinariesServer(t, map[string]string{
+		"0.0.1": "soljson-v0.0.1.js",
+		"0.0.2": "soljson-v0.0.2.js",
+	}, map[string]string{
+		"soljson-v0.0.2.js": "throw new Error('Module is broken')",
+	}, false)
+
+	var downloadErr *DownloadError
+	var resolveErr *ResolveError
+	var initErr *InitError
+
+	// Unknown versions are not worth retrying
+	_, err := NewWithVersion("0.0.0-unknown")
+	require.ErrorAs(t, err, &resolveErr)
+	assert.Equal(t, "0.0.0-unknown", resolveErr.Version)
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+	assert.False(t, errors.As(err, &downloadErr))
+
+	// Failed downloads are
+	_, err = NewWithVersion("0.0.1")
+	require.ErrorAs(t, err, &downloadErr)
+	assert.Equal(t, "0.0.1", downloadErr.Version)
+	assert.Contains(t, err.Error(), "HTTP 404")
+	assert.False(t, errors.As(err, &initErr))
+
+	// A downloaded binary that does not run fails to initialize
+	_, err = NewWithVersion("0.0.2")
+	require.ErrorAs(t, err, &initErr)
+	assert.ErrorIs(t, err, ErrCompilerInit)
+	assert.False(t, errors.As(err, &downloadErr))
+
+	// An unreachable host fails the version list download
+	server.Close()
+	resetVersionListCache()
+	_, err = NewWithVersion("0.0.3")
+	require.ErrorAs(t, err, &downloadErr)
+	assert.False(t, errors.As(err, &resolveErr))
+}
+
+func TestCorruptCachedBinary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	newTestBinariesServer(t, map[string]string{
+		"0.0.1": "soljson-v0.0.1.js",
+	}, map[string]string{
+		"soljson-v0.0.1.js": "var Module = {};",
+	})
+
+	// A truncated or empty cache entry is treated as a miss and re-downloaded
+	for _, corrupt := range []string{"", "<html>502 Bad Gateway</html>"} {
+		require.NoError(t, saveBinaryToCache("0.0.1", corrupt))
+		_, found := loadCachedBinary("0.0.1")
+		assert.False(t, found)
+
+		content, err := downloadSolcBinary("0.0.1", "soljson-v0.0.1.js")
+		require.NoError(t, err)
+		assert.Equal(t, "var Module = {};", content)
+
+		// The cache heals with the fresh download
+		cached, found := loadCachedBinary("0.0.1")
+		require.True(t, found)
+		assert.Equal(t, "var Module = {};", cached)
+	}
+}
+
+func TestChecksummedBinaryCache(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	binary := "var Module = {};"
+	sum := sha256.Sum256([]byte(binary))
+	checksum := hex.EncodeToString(sum[:])
+
+	var served atomic.Value
+	served.Store(binary)
+	var downloads atomic.Int32
+	var offline atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list.json" {
+			json.NewEncoder(w).Encode(VersionList{
+				Builds: []Build{{Path: "soljson-v0.0.1.js", Version: "0.0.1", SHA256: "0x" + checksum}},
+				Releases: map[string]string{
+					"0.0.1":       "soljson-v0.0.1.js",
+					"0.0.1-alias": "soljson-v0.0.1.js",
+				},
+			})
+			return
+		}
+		if offline.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		downloads.Add(1)
+		w.Write([]byte(served.Load().(string)))
+	}))
+	t.Cleanup(server.Close)
+	originalURL := binariesBaseURL
+	binariesBaseURL = server.URL
+	SetDownloadMirrors()
+	resetVersionListCache()
+	t.Cleanup(func() {
+		binariesBaseURL = originalURL
+		SetDownloadMirrors(SOLC_BIN_GITHUB_URL)
+		resetVersionListCache()
+	})
+
+	download := func(version string) (string, error) {
+		filename, err := resolveVersion(version)
+		require.NoError(t, err)
+		return downloadSolcBinary(version, filename)
+	}
+
+	// The binary is verified and cached under its checksum
+	content, err := download("0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, binary, content)
+	cachePath := filepath.Join(home, "solc", "sha256", checksum+".js")
+	assert.FileExists(t, cachePath)
+	assert.NoDirExists(t, filepath.Join(home, "solc", "0.0.1"))
+
+	// Versions resolving to the same file share the entry
+	content, err = download("0.0.1-alias")
+	require.NoError(t, err)
+	assert.Equal(t, binary, content)
+	assert.Equal(t, int32(1), downloads.Load())
+
+	// A cached file that no longer matches is downloaded again
+	require.NoError(t, os.WriteFile(cachePath, []byte("var Module = {}; // tampered"), 0644))
+	content, err = download("0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, binary, content)
+	assert.Equal(t, int32(2), downloads.Load())
+
+	// A changed upstream file is rejected and not cached
+	require.NoError(t, os.Remove(cachePath))
+	served.Store("var Module = {}; // changed upstream")
+	_, err = download("0.0.1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sha256 checksum mismatch")
+	assert.Contains(t, err.Error(), server.URL+"/soljson-v0.0.1.js")
+	assert.NoFileExists(t, cachePath)
+
+	// A matching binary in the version cache is used without downloading,
+	// and copied to the checksum cache
+	offline.Store(true)
+	require.NoError(t, saveBinaryToCache("0.0.1", binary))
+	content, err = download("0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, binary, content)
+	assert.FileExists(t, cachePath)
+
+	// but not if it does not match
+	require.NoError(t, os.Remove(cachePath))
+	require.NoError(t, saveBinaryToCache("0.0.1", "var Module = {}; // stale"))
+	_, err = download("0.0.1")
+	assert.ErrorContains(t, err, "HTTP 503")
+}
+
+func TestDownloadMirrorFallback(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mirror, mirrorListRequests := startTestBinariesServer(t, map[string]string{
+		"0.0.1": "soljson-v0.0.1.js",
+	}, map[string]string{
+		"soljson-v0.0.1.js": "// mirrored binary",
+	}, false)
+
+	var primaryRequests atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryRequests.Add(1)
+		http.Error(w, "outage", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(primary.Close)
+	binariesBaseURL = primary.URL
+	SetDownloadMirrors(mirror.URL)
+
+	// A primary failure transparently falls back to the mirror
+	filename, err := resolveVersion("0.0.1")
+	require.NoError(t, err)
+	content, err := downloadSolcBinary("0.0.1", filename)
+	require.NoError(t, err)
+	assert.Equal(t, "// mirrored binary", content)
+	assert.Equal(t, int32(2), primaryRequests.Load(), "Primary host should be tried first")
+	assert.Equal(t, int32(1), mirrorListRequests.Load())
+
+	// When every host fails, the error names each attempted URL
+	SetDownloadMirrors(primary.URL + "/mirror")
+	resetVersionListCache()
+	_, err = fetchVersionList()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), primary.URL+"/list.json")
+	assert.Contains(t, err.Error(), primary.URL+"/mirror/list.json")
+	assert.Contains(t, err.Error(), "HTTP 503")
+}
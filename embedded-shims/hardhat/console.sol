@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: MIT
+pragma solidity >=0.8.0 <0.9.0;
+
+// Minimal console.log shim compatible with Hardhat's console.sol. Calls are
+// sent to the console address, which Hardhat-compatible nodes intercept; on
+// other chains they are no-ops.
+library console {
+    address constant CONSOLE_ADDRESS = 0x000000000000000000636F6e736F6c652e6c6f67;
+
+    function _sendLogPayloadImplementation(bytes memory payload) internal view {
+        address consoleAddress = CONSOLE_ADDRESS;
+        assembly {
+            pop(staticcall(gas(), consoleAddress, add(payload, 32), mload(payload), 0, 0))
+        }
+    }
+
+    function _castToPure(
+        function(bytes memory) internal view fnIn
+    ) internal pure returns (function(bytes memory) pure fnOut) {
+        assembly {
+            fnOut := fnIn
+        }
+    }
+
+    function _sendLogPayload(bytes memory payload) internal pure {
+        _castToPure(_sendLogPayloadImplementation)(payload);
+    }
+
+    function log() internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log()"));
+    }
+
+    function logInt(int256 p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(int256)", p0));
+    }
+
+    function logUint(uint256 p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(uint256)", p0));
+    }
+
+    function logString(string memory p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(string)", p0));
+    }
+
+    function logBool(bool p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bool)", p0));
+    }
+
+    function logAddress(address p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(address)", p0));
+    }
+
+    function logBytes(bytes memory p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bytes)", p0));
+    }
+
+    function logBytes32(bytes32 p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bytes32)", p0));
+    }
+
+    function log(int256 p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(int256)", p0));
+    }
+
+    function log(uint256 p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(uint256)", p0));
+    }
+
+    function log(string memory p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(string)", p0));
+    }
+
+    function log(bool p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bool)", p0));
+    }
+
+    function log(address p0) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(address)", p0));
+    }
+
+    function log(uint256 p0, uint256 p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(uint256,uint256)", p0, p1));
+    }
+
+    function log(uint256 p0, string memory p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(uint256,string)", p0, p1));
+    }
+
+    function log(uint256 p0, bool p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(uint256,bool)", p0, p1));
+    }
+
+    function log(uint256 p0, address p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(uint256,address)", p0, p1));
+    }
+
+    function log(string memory p0, uint256 p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(string,uint256)", p0, p1));
+    }
+
+    function log(string memory p0, string memory p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(string,string)", p0, p1));
+    }
+
+    function log(string memory p0, bool p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(string,bool)", p0, p1));
+    }
+
+    function log(string memory p0, address p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(string,address)", p0, p1));
+    }
+
+    function log(bool p0, uint256 p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bool,uint256)", p0, p1));
+    }
+
+    function log(bool p0, string memory p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bool,string)", p0, p1));
+    }
+
+    function log(bool p0, bool p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bool,bool)", p0, p1));
+    }
+
+    function log(bool p0, address p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(bool,address)", p0, p1));
+    }
+
+    function log(address p0, uint256 p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(address,uint256)", p0, p1));
+    }
+
+    function log(address p0, string memory p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(address,string)", p0, p1));
+    }
+
+    function log(address p0, bool p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(address,bool)", p0, p1));
+    }
+
+    function log(address p0, address p1) internal pure {
+        _sendLogPayload(abi.encodeWithSignature("log(address,address)", p0, p1));
+    }
+}
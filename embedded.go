@@ -2,6 +2,11 @@ package solc
 
 import (
 	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // Embedded Solidity compiler binaries
@@ -33,3 +38,89 @@ func GetEmbeddedVersions() []string {
 	}
 	return versions
 }
+
+// registeredVersions holds binaries registered at runtime via RegisterBinary
+var (
+	registeredMu       sync.RWMutex
+	registeredVersions = map[string]string{}
+)
+
+// RegisterBinary registers a soljson.js binary obtained by the caller as the
+// given version, so that subsequent NewWithVersion calls use it without
+// downloading. Registered binaries take precedence over embedded ones.
+func RegisterBinary(version, soljsonjs string) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registeredVersions[version] = soljsonjs
+}
+
+// RegisterAndCacheBinary registers a binary like RegisterBinary and also
+// writes it to the disk cache of registered binaries, so that NewWithVersion
+// finds it in later processes too, without resolving or downloading the
+// version.
+func RegisterAndCacheBinary(version, soljsonjs string) error {
+	if err := saveRegisteredBinary(version, soljsonjs); err != nil {
+		return fmt.Errorf("failed to cache binary for version %s: %w", version, err)
+	}
+	RegisterBinary(version, soljsonjs)
+	return nil
+}
+
+// getRegisteredBinaryPath returns the path of a binary cached by
+// RegisterAndCacheBinary. They are kept apart from downloaded binaries, so
+// custom builds are not checked against the checksums of official ones.
+func getRegisteredBinaryPath(version string) (string, error) {
+	if version == "" || strings.ContainsAny(version, `/\`) {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "registered", version+".js"), nil
+}
+
+// saveRegisteredBinary writes a binary to the disk cache of registered binaries.
+func saveRegisteredBinary(version, soljsonjs string) error {
+	cachePath, err := getRegisteredBinaryPath(version)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath, []byte(soljsonjs), 0644)
+}
+
+// loadRegisteredBinary returns the binary registered for a version, in this
+// process or cached on disk by RegisterAndCacheBinary, registering the
+// latter so the file is read only once.
+func loadRegisteredBinary(version string) (string, bool) {
+	if binary, exists := getRegisteredBinary(version); exists {
+		return binary, true
+	}
+
+	cachePath, err := getRegisteredBinaryPath(version)
+	if err != nil {
+		return "", false
+	}
+	content, err := os.ReadFile(cachePath)
+	if err != nil {
+		return "", false
+	}
+	if err := validateBinary(string(content)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring registered binary for version %s: %v\n", version, err)
+		return "", false
+	}
+
+	RegisterBinary(version, string(content))
+	return string(content), true
+}
+
+// getRegisteredBinary returns the binary registered for a given version if available
+func getRegisteredBinary(version string) (string, bool) {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	binary, exists := registeredVersions[version]
+	return binary, exists
+}
@@ -87,3 +87,56 @@ func TestNewWithVersionEmbedded(t *testing.T) {
 		t.Error("License should not be empty")
 	}
 }
+
+func TestRegisterBinary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	binary, exists := getEmbeddedBinary("0.8.21")
+	if !exists {
+		t.Fatal("0.8.21 embedded binary not found")
+	}
+
+	// A registered version is used without downloading
+	RegisterBinary("0.0.0-registered", binary)
+	solc, err := NewWithVersion("0.0.0-registered")
+	if err != nil {
+		t.Fatalf("Failed to create solc with registered version: %v", err)
+	}
+	defer solc.Close()
+
+	if !strings.Contains(solc.Version(), "0.8.21") {
+		t.Errorf("Expected version to contain 0.8.21, got: %s", solc.Version())
+	}
+
+	// Registering with cache makes the binary available to later processes,
+	// which have nothing registered and cannot resolve the custom version
+	if err := RegisterAndCacheBinary("0.0.0-cached", binary); err != nil {
+		t.Fatalf("Failed to register and cache binary: %v", err)
+	}
+	registeredMu.Lock()
+	delete(registeredVersions, "0.0.0-cached")
+	registeredMu.Unlock()
+
+	originalURL := binariesBaseURL
+	binariesBaseURL = "http://127.0.0.1:0"
+	SetDownloadMirrors()
+	resetVersionListCache()
+	t.Cleanup(func() {
+		binariesBaseURL = originalURL
+		SetDownloadMirrors(SOLC_BIN_GITHUB_URL)
+		resetVersionListCache()
+	})
+
+	cached, err := NewWithVersion("0.0.0-cached")
+	if err != nil {
+		t.Fatalf("Failed to create solc with cached registered version: %v", err)
+	}
+	defer cached.Close()
+	if !strings.Contains(cached.Version(), "0.8.21") {
+		t.Errorf("Expected version to contain 0.8.21, got: %s", cached.Version())
+	}
+
+	if err := RegisterAndCacheBinary("../escape", binary); err == nil {
+		t.Error("Versions containing path separators should be rejected")
+	}
+}
@@ -0,0 +1,158 @@
+package solc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped) by the package, for use with errors.Is.
+var (
+	// ErrVersionNotFound is returned when a requested compiler version is not
+	// a known release, or no release satisfies a version constraint. Lookups
+	// of unknown versions are answered from the memoized version list, so they
+	// do not refetch it.
+	ErrVersionNotFound = errors.New("compiler version not found")
+
+	// ErrClosed is returned when a compiler is used after it has been closed.
+	ErrClosed = errors.New("compiler has been closed")
+
+	// ErrImportFailed is returned when the imports of an input cannot be
+	// resolved, e.g. because the import callback reported an error or an
+	// imported file is missing.
+	ErrImportFailed = errors.New("import resolution failed")
+
+	// ErrCompilerInit is returned, wrapped by an *InitError, when a compiler
+	// instance cannot be created from a soljson.js binary.
+	ErrCompilerInit = errors.New("failed to initialize compiler")
+
+	// ErrNoPragma is returned by ParsePragma when a source has no version pragma.
+	ErrNoPragma = errors.New("no version pragma found")
+
+	// ErrCompilationFailed is wrapped by the DiagnosticsError returned with
+	// CompileOptions.StrictWarnings or FailFast when the compiler reported errors.
+	ErrCompilationFailed = errors.New("compilation failed")
+
+	// ErrWarnings is wrapped by the DiagnosticsError returned with
+	// CompileOptions.StrictWarnings when the compiler reported warnings only.
+	ErrWarnings = errors.New("compilation produced warnings")
+)
+
+// DownloadError is returned by NewWithVersion and related constructors when
+// the version list or the compiler binary cannot be fetched, e.g. because
+// of a network failure or an HTTP error from every host. Such failures are
+// usually transient and worth retrying.
+type DownloadError struct {
+	// Version is the requested compiler version.
+	Version string
+	Err     error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("failed to download solc binary for version %s: %v", e.Version, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// ResolveError is returned by NewWithVersion and related constructors when
+// the requested version is not a known release. It wraps ErrVersionNotFound;
+// retrying does not help.
+type ResolveError struct {
+	// Version is the requested compiler version.
+	Version string
+	Err     error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("failed to resolve version %s: %v", e.Version, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// InitError is returned when a compiler instance cannot be created from a
+// soljson.js binary, e.g. because the binary is broken or V8 fails. It
+// wraps ErrCompilerInit as well as the underlying error.
+type InitError struct {
+	Err error
+}
+
+func (e *InitError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCompilerInit, e.Err)
+}
+
+// Unwrap returns ErrCompilerInit and the underlying error.
+func (e *InitError) Unwrap() []error {
+	return []error{ErrCompilerInit, e.Err}
+}
+
+// DiagnosticsError is returned in strict or fail-fast mode when a compilation
+// reports errors (or, in strict mode, warnings). It wraps
+// ErrCompilationFailed if there are errors, which take precedence, and
+// ErrWarnings otherwise.
+type DiagnosticsError struct {
+	// Diagnostics holds the errors and warnings, errors first, each in the
+	// order reported by the compiler.
+	Diagnostics []Error
+	err         error
+}
+
+// Error describes the first diagnostic and how many more there are.
+func (e *DiagnosticsError) Error() string {
+	if len(e.Diagnostics) == 0 {
+		return e.err.Error()
+	}
+	message := fmt.Sprintf("%v: %s", e.err, e.Diagnostics[0].Message)
+	if len(e.Diagnostics) > 1 {
+		message += fmt.Sprintf(" (and %d more)", len(e.Diagnostics)-1)
+	}
+	return message
+}
+
+// Unwrap returns ErrCompilationFailed or ErrWarnings.
+func (e *DiagnosticsError) Unwrap() error {
+	return e.err
+}
+
+// strictDiagnostics returns a DiagnosticsError if the output contains errors
+// or warnings, and nil otherwise.
+func strictDiagnostics(output *Output) error {
+	var errs, warnings []Error
+	for _, e := range output.Errors {
+		switch {
+		case e.IsError():
+			errs = append(errs, e)
+		case e.IsWarning():
+			warnings = append(warnings, e)
+		}
+	}
+
+	switch {
+	case len(errs) > 0:
+		return &DiagnosticsError{Diagnostics: append(errs, warnings...), err: ErrCompilationFailed}
+	case len(warnings) > 0:
+		return &DiagnosticsError{Diagnostics: warnings, err: ErrWarnings}
+	default:
+		return nil
+	}
+}
+
+// diagnosticsError applies CompileOptions.FailFast and StrictWarnings to an
+// output. FailFast reports the first error only and takes precedence.
+func diagnosticsError(output *Output, strict, failFast bool) error {
+	if failFast {
+		for _, e := range output.Errors {
+			if e.IsError() {
+				return &DiagnosticsError{Diagnostics: []Error{e}, err: ErrCompilationFailed}
+			}
+		}
+	}
+	if strict {
+		return strictDiagnostics(output)
+	}
+	return nil
+}
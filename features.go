@@ -0,0 +1,87 @@
+package solc
+
+// Feature names accepted by Solc.Supports. Output selection features are named
+// after their standard JSON output selection key and settings after their
+// settings key.
+const (
+	FeatureStorageLayout       = "storageLayout"
+	FeatureIR                  = "ir"
+	FeatureIROptimized         = "irOptimized"
+	FeatureImmutableReferences = "immutableReferences"
+	FeatureGeneratedSources    = "generatedSources"
+	FeatureFunctionDebugData   = "functionDebugData"
+	FeatureViaIR               = "viaIR"
+	FeatureModelChecker        = "modelChecker"
+	FeatureStopAfter           = "stopAfter"
+	FeatureBytecodeHash        = "bytecodeHash"
+	FeatureAppendCBOR          = "appendCBOR"
+	FeatureCustomErrors        = "customErrors"
+	FeatureEOF                 = "eof"
+)
+
+// featureMinVersions maps features to the first compiler release supporting them
+var featureMinVersions = map[string]semver{
+	FeatureStorageLayout:       {0, 5, 13},
+	FeatureIR:                  {0, 6, 0},
+	FeatureIROptimized:         {0, 6, 0},
+	FeatureImmutableReferences: {0, 6, 5},
+	FeatureGeneratedSources:    {0, 7, 2},
+	FeatureFunctionDebugData:   {0, 8, 3},
+	FeatureViaIR:               {0, 7, 5},
+	FeatureModelChecker:        {0, 7, 5},
+	FeatureStopAfter:           {0, 7, 3},
+	FeatureBytecodeHash:        {0, 6, 0},
+	FeatureAppendCBOR:          {0, 8, 18},
+	FeatureCustomErrors:        {0, 8, 4},
+	FeatureEOF:                 {0, 8, 29},
+}
+
+// versionSupports reports whether the given compiler version supports a feature.
+// Unknown features and unparsable versions are reported as unsupported.
+func versionSupports(version, feature string) bool {
+	minVersion, known := featureMinVersions[feature]
+	if !known {
+		return false
+	}
+
+	v, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+
+	return v.compare(minVersion) >= 0
+}
+
+// defaultEVMVersions lists the first compiler release of each default EVM
+// version, newest first
+var defaultEVMVersions = []struct {
+	since      semver
+	evmVersion string
+}{
+	{semver{0, 8, 30}, "prague"},
+	{semver{0, 8, 25}, "cancun"},
+	{semver{0, 8, 20}, "shanghai"},
+	{semver{0, 8, 18}, "paris"},
+	{semver{0, 8, 7}, "london"},
+	{semver{0, 8, 5}, "berlin"},
+	{semver{0, 5, 14}, "istanbul"},
+	{semver{0, 5, 5}, "petersburg"},
+	{semver{0, 4, 21}, "byzantium"},
+}
+
+// defaultEVMVersion returns the EVM version targeted by default by the given
+// compiler version. Versions before the evmVersion setting was introduced
+// (0.4.21) target homestead; unparsable versions yield an empty string.
+func defaultEVMVersion(version string) string {
+	v, err := parseSemver(version)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range defaultEVMVersions {
+		if v.compare(entry.since) >= 0 {
+			return entry.evmVersion
+		}
+	}
+	return "homestead"
+}
@@ -0,0 +1,81 @@
+package solc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionSupports(t *testing.T) {
+	tests := []struct {
+		version  string
+		feature  string
+		expected bool
+	}{
+		{"0.5.12+commit.7709ece9", FeatureStorageLayout, false},
+		{"0.5.13+commit.5b0b510c", FeatureStorageLayout, true},
+		{"0.8.21+commit.d9974bed.Emscripten.clang", FeatureIROptimized, true},
+		{"0.4.26+commit.4563c3fc", FeatureIR, false},
+		{"0.8.3", FeatureCustomErrors, false},
+		{"0.8.4", FeatureCustomErrors, true},
+		{"0.8.21", "unknownFeature", false},
+		{"garbage", FeatureIR, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, versionSupports(tt.version, tt.feature), "%s on %s", tt.feature, tt.version)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	assert.True(t, compiler.Supports(FeatureStorageLayout))
+	assert.True(t, compiler.Supports(FeatureAppendCBOR))
+	assert.False(t, compiler.Supports(FeatureEOF))
+}
+
+func TestDefaultEVMVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"0.4.20", "homestead"},
+		{"0.4.26+commit.4563c3fc", "byzantium"},
+		{"0.5.17", "istanbul"},
+		{"0.8.6", "berlin"},
+		{"0.8.17", "london"},
+		{"0.8.19", "paris"},
+		{"0.8.24", "shanghai"},
+		{"0.8.29", "cancun"},
+		{"0.8.31", "prague"},
+		{"garbage", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, defaultEVMVersion(tt.version), tt.version)
+	}
+
+	// The table matches what the compilers record in their metadata
+	for _, version := range []string{"0.8.21", "0.8.30"} {
+		output := compileSources(t, version, map[string]SourceIn{
+			"Checked.sol": {Content: assertContract},
+		}, "metadata")
+		require.Empty(t, output.Errors)
+
+		compiler, err := NewWithVersion(version)
+		require.NoError(t, err)
+		defer compiler.Close()
+
+		var metadata struct {
+			Settings struct {
+				EVMVersion string `json:"evmVersion"`
+			} `json:"settings"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output.Contracts["Checked.sol"]["Checked"].Metadata), &metadata))
+		assert.Equal(t, metadata.Settings.EVMVersion, compiler.DefaultEVMVersion(), version)
+	}
+}
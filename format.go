@@ -0,0 +1,88 @@
+package solc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FormatOptions controls how diagnostics are rendered by FormatErrorsWithOptions.
+type FormatOptions struct {
+	// Color highlights the header line of each diagnostic with ANSI colors by
+	// severity. It is ignored when the NO_COLOR environment variable is set,
+	// so leave it off for output that is not a terminal.
+	Color bool
+}
+
+// ANSI escape sequences used to highlight diagnostics
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiCyan   = "\x1b[1;36m"
+)
+
+// FormatErrors renders all errors, warnings and infos of the output into a
+// single string for terminals, in the order reported by the compiler. Each
+// diagnostic starts with its severity and type and includes solc's source
+// context when available. It returns an empty string if there are none.
+func (o *Output) FormatErrors() string {
+	return o.FormatErrorsWithOptions(nil)
+}
+
+// FormatErrorsWithOptions is like FormatErrors, configured by options. Pass
+// nil for options to render without colors.
+func (o *Output) FormatErrorsWithOptions(options *FormatOptions) string {
+	color := options != nil && options.Color && os.Getenv("NO_COLOR") == ""
+
+	var b strings.Builder
+	for i, e := range o.Errors {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+
+		message := strings.TrimRight(e.format(), "\n")
+		if color {
+			header, rest, _ := strings.Cut(message, "\n")
+			b.WriteString(severityColor(e.Severity) + header + ansiReset)
+			if rest != "" {
+				b.WriteString("\n" + rest)
+			}
+		} else {
+			b.WriteString(message)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// format returns solc's formatted message, or one built from the fields for
+// diagnostics without it, such as outputs parsed from elsewhere.
+func (e Error) format() string {
+	if e.FormattedMessage != "" {
+		return e.FormattedMessage
+	}
+
+	header := e.Type
+	if header == "" {
+		header = e.Severity
+	}
+	message := fmt.Sprintf("%s: %s", header, e.Message)
+	if e.SourceLocation.File != "" {
+		// Only byte offsets are known without the source, unlike solc's line:column
+		message += fmt.Sprintf("\n --> %s, bytes %d-%d", e.SourceLocation.File, e.SourceLocation.Start, e.SourceLocation.End)
+	}
+	return message
+}
+
+// severityColor returns the ANSI color for a diagnostic severity.
+func severityColor(severity string) string {
+	switch severity {
+	case "error":
+		return ansiRed
+	case "warning":
+		return ansiYellow
+	default:
+		return ansiCyan
+	}
+}
@@ -0,0 +1,47 @@
+package solc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatErrors(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Broken.sol": {Content: `pragma solidity ^0.8.0;
+contract Broken {
+    function f() public {
+        uint256 unused;
+        undefinedCall();
+    }
+}`},
+	}, "abi")
+	require.True(t, output.HasErrors())
+
+	formatted := output.FormatErrors()
+	assert.Contains(t, formatted, "DeclarationError: Undeclared identifier.")
+	assert.Contains(t, formatted, "--> Broken.sol:5:9", "Source context should be included")
+	assert.Contains(t, formatted, "undefinedCall();")
+	assert.NotContains(t, formatted, "\x1b[", "Colors should be off by default")
+	assert.True(t, strings.HasSuffix(formatted, "\n"))
+
+	// Colors highlight the header of each diagnostic by severity
+	t.Setenv("NO_COLOR", "")
+	colored := output.FormatErrorsWithOptions(&FormatOptions{Color: true})
+	assert.Contains(t, colored, ansiRed+"DeclarationError: Undeclared identifier."+ansiReset)
+
+	// NO_COLOR takes precedence
+	t.Setenv("NO_COLOR", "1")
+	assert.Equal(t, formatted, output.FormatErrorsWithOptions(&FormatOptions{Color: true}))
+
+	// Diagnostics without a formatted message are rendered from their fields
+	parsed := &Output{Errors: []Error{
+		{Severity: "warning", Type: "Warning", Message: "Unused local variable.", SourceLocation: SourceLocation{File: "A.sol", Start: 10, End: 24}},
+		{Severity: "info", Message: "Consider using the optimizer."},
+	}}
+	assert.Equal(t, "Warning: Unused local variable.\n --> A.sol, bytes 10-24\n\ninfo: Consider using the optimizer.\n", parsed.FormatErrors())
+
+	assert.Empty(t, (&Output{}).FormatErrors())
+}
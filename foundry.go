@@ -0,0 +1,59 @@
+package solc
+
+import (
+	"strings"
+)
+
+// foundryDefaultOptimizerRuns is forge's default for optimizer_runs.
+const foundryDefaultOptimizerRuns = 200
+
+// FoundryConfig holds the compiler settings of a foundry.toml profile that
+// map onto the standard JSON settings. It does not parse TOML; the toml tags
+// match Foundry's keys, so it can be decoded with any TOML library.
+type FoundryConfig struct {
+	// Optimizer enables the optimizer.
+	Optimizer bool `toml:"optimizer"`
+	// OptimizerRuns is the number of optimizer runs, only used with Optimizer.
+	// Nil, for a missing key, becomes forge's default of 200.
+	OptimizerRuns *int `toml:"optimizer_runs"`
+	// EVMVersion is the EVM version to target, e.g. "paris". Empty uses the
+	// compiler's default.
+	EVMVersion string `toml:"evm_version"`
+	// Remappings are import remappings such as "@oz/=lib/openzeppelin-contracts/".
+	Remappings []string `toml:"remappings"`
+	// ViaIR enables the IR-based code generator.
+	ViaIR bool `toml:"via_ir"`
+	// BytecodeHash is the metadata hash appended to the bytecode: "ipfs",
+	// "bzzr1" or "none". Empty uses the compiler's default.
+	BytecodeHash string `toml:"bytecode_hash"`
+	// CBORMetadata, when set to false, leaves out the CBOR metadata trailer.
+	CBORMetadata *bool `toml:"cbor_metadata"`
+}
+
+// Settings translates the config into standard JSON settings, e.g. to
+// compile a Foundry project with the same settings as forge. The output
+// selection is left empty; set it as needed.
+func (c FoundryConfig) Settings() Settings {
+	runs := foundryDefaultOptimizerRuns
+	if c.OptimizerRuns != nil {
+		runs = *c.OptimizerRuns
+	}
+	settings := Settings{
+		Remappings: cloneStrings(c.Remappings),
+		Optimizer: Optimizer{
+			Enabled:      c.Optimizer,
+			Runs:         runs,
+			ExplicitRuns: runs == 0,
+		},
+		// Foundry accepts EVM versions in any case, solc only in lower case
+		EVMVersion: strings.ToLower(c.EVMVersion),
+		ViaIR:      c.ViaIR,
+	}
+	if c.BytecodeHash != "" || c.CBORMetadata != nil {
+		settings.Metadata = &Metadata{
+			BytecodeHash: c.BytecodeHash,
+			AppendCBOR:   clonePointer(c.CBORMetadata),
+		}
+	}
+	return settings
+}
@@ -0,0 +1,69 @@
+package solc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoundryConfigSettings(t *testing.T) {
+	cbor, runs := false, 10000
+	config := FoundryConfig{
+		Optimizer:     true,
+		OptimizerRuns: &runs,
+		EVMVersion:    "Paris",
+		Remappings:    []string{"@oz/=lib/openzeppelin-contracts/"},
+		ViaIR:         true,
+		BytecodeHash:  "none",
+		CBORMetadata:  &cbor,
+	}
+	settings := config.Settings()
+	assert.Equal(t, Settings{
+		Remappings: []string{"@oz/=lib/openzeppelin-contracts/"},
+		Optimizer:  Optimizer{Enabled: true, Runs: 10000},
+		EVMVersion: "paris",
+		ViaIR:      true,
+		Metadata:   &Metadata{BytecodeHash: "none", AppendCBOR: &cbor},
+	}, settings)
+
+	// The settings do not alias the config
+	settings.Remappings[0] = "changed"
+	*settings.Metadata.AppendCBOR = true
+	assert.Equal(t, "@oz/=lib/openzeppelin-contracts/", config.Remappings[0])
+	assert.False(t, *config.CBORMetadata)
+
+	// Defaults leave the compiler's defaults in place
+	data, err := json.Marshal(FoundryConfig{}.Settings())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"optimizer":{}}`, string(data))
+
+	// Unset runs default to forge's 200, while an explicit zero is kept
+	assert.Equal(t, Optimizer{Enabled: true, Runs: 200}, FoundryConfig{Optimizer: true}.Settings().Optimizer)
+	data, err = json.Marshal(FoundryConfig{Optimizer: true}.Settings())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"optimizer":{"enabled":true,"runs":200}}`, string(data))
+	zero := 0
+	data, err = json.Marshal(FoundryConfig{Optimizer: true, OptimizerRuns: &zero}.Settings())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"optimizer":{"enabled":true,"runs":0}}`, string(data))
+
+	// The settings compile a project laid out like a Foundry one
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"src/Token.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+import "@oz/access/Ownable.sol";
+contract Token is Ownable {}`},
+			"lib/openzeppelin-contracts/access/Ownable.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+contract Ownable {}`},
+		},
+		Settings: config.Settings(),
+	}
+	output := compileInput(t, "0.8.21", input)
+	require.False(t, output.HasErrors(), output.Errors)
+	assert.NotEmpty(t, output.Contracts["src/Token.sol"]["Token"].EVM.DeployedBytecode.Object)
+}
@@ -4,11 +4,13 @@ go 1.24
 
 require (
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/crypto v0.36.0
 	rogchap.com/v8go v0.9.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
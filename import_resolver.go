@@ -1,18 +1,35 @@
 package solc
 
 import (
+	"errors"
 	"fmt"
-	"path/filepath"
+	"path"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
+// defaultMaxImportDepth is the maximum length of an import chain unless
+// CompileOptions.MaxImportDepth overrides it.
+const defaultMaxImportDepth = 50
+
 // importResolver handles the recursive resolution of Solidity imports
 type importResolver struct {
 	importCallback  ImportCallback
-	resolvedSources map[string]bool // tracks resolved imports to avoid cycles
-	contextStack    []string        // current import context for relative path resolution
-	maxDepth        int             // maximum recursion depth
+	resolvedSources map[string]bool   // tracks resolved imports to avoid cycles
+	aliases         map[string]string // requested import paths remapped to a callback-reported path
+	contextStack    []string          // current import context for relative path resolution
+	basePath        string            // directory source unit names are loaded from
+	includePaths    []string          // directories searched after the base path
+	maxDepth        int               // maximum recursion depth
+	timeout         time.Duration     // maximum duration of a single callback, zero for none
+	concurrency     int               // maximum number of concurrent callbacks
+	failFast        bool              // stop at the first failed import
+	failed          map[string]bool   // imports the callback failed to resolve
+	errs            []error           // import failures collected unless failFast
+	callbackCount   int               // number of import callback invocations
 }
 
 // newImportResolver creates a new import resolver
@@ -20,31 +37,129 @@ func newImportResolver(callback ImportCallback) *importResolver {
 	return &importResolver{
 		importCallback:  callback,
 		resolvedSources: make(map[string]bool),
+		aliases:         make(map[string]string),
+		failed:          make(map[string]bool),
 		contextStack:    []string{},
-		maxDepth:        50,
+		maxDepth:        defaultMaxImportDepth,
+	}
+}
+
+// ResolveImportGraph resolves all transitive imports of the input sources
+// through the callback without invoking the compiler, and returns the sources
+// together with every imported file, keyed by source unit name. The input is
+// left unchanged. This is useful to build lockfiles or prefetch dependencies.
+// A nil callback reports the imports missing from the input sources.
+func ResolveImportGraph(input *Input, callback ImportCallback) (map[string]SourceIn, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input cannot be nil")
+	}
+	if callback == nil {
+		callback = func(url string) ImportResult {
+			return ImportResult{Error: "no import callback configured"}
+		}
+	}
+
+	resolved, _, err := resolveInputCopy(input, callback, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Sources, nil
+}
+
+// resolveInputCopy resolves the imports of a shallow copy of the input with
+// its own sources map, leaving the input unchanged. The resolver is
+// configured by the import settings of options, which may be nil.
+func resolveInputCopy(input *Input, callback ImportCallback, options *CompileOptions) (*Input, *importResolver, error) {
+	resolved := *input
+	resolved.Sources = make(map[string]SourceIn, len(input.Sources))
+	for name, source := range input.Sources {
+		resolved.Sources[name] = source
+	}
+
+	resolver := newImportResolver(callback)
+	resolver.configure(options)
+	if _, err := resolver.resolveImports(&resolved); err != nil {
+		return nil, resolver, fmt.Errorf("%w: %w", ErrImportFailed, err)
 	}
+	return &resolved, resolver, nil
 }
 
-// resolveImports recursively resolves all imports in the input
+// configure applies the import related settings of options, which may be nil.
+func (r *importResolver) configure(options *CompileOptions) {
+	if options == nil {
+		return
+	}
+	if options.MaxImportDepth > 0 {
+		r.maxDepth = options.MaxImportDepth
+	}
+	r.basePath = options.BasePath
+	r.includePaths = options.IncludePaths
+	r.timeout = options.ImportTimeout
+	r.concurrency = options.ImportConcurrency
+	r.failFast = options.ImportFailFast
+}
+
+// resolveImports recursively resolves all imports in the input. Unless
+// failFast is set, imports the callback fails to resolve do not stop the
+// resolution, and all of them are returned joined with errors.Join.
 func (r *importResolver) resolveImports(input *Input) (*Input, error) {
 	if input.Sources == nil {
 		input.Sources = make(map[string]SourceIn)
 	}
 
-	// Recursively resolve imports for each source file
+	// Recursively resolve imports for each source file, in a stable order
+	// so that collected failures are reported deterministically
+	fileNames := make([]string, 0, len(input.Sources))
 	for fileName := range input.Sources {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	for _, fileName := range fileNames {
 		if err := r.resolveFileImports(input, fileName, 0); err != nil {
 			return nil, err
 		}
 	}
+	if len(r.errs) > 0 {
+		return nil, errors.Join(r.errs...)
+	}
+
+	r.addAliasRemappings(input)
 
 	return input, nil
 }
 
+// addAliasRemappings remaps the requested paths of imports whose callback
+// reported a different resolved path, so solc finds them under their source key.
+func (r *importResolver) addAliasRemappings(input *Input) {
+	if len(r.aliases) == 0 {
+		return
+	}
+
+	existing := make(map[string]bool)
+	for _, remapping := range input.Settings.Remappings {
+		existing[remapping] = true
+	}
+
+	requested := make([]string, 0, len(r.aliases))
+	for requestedPath := range r.aliases {
+		requested = append(requested, requestedPath)
+	}
+	sort.Strings(requested)
+
+	remappings := append([]string(nil), input.Settings.Remappings...)
+	for _, requestedPath := range requested {
+		remapping := requestedPath + "=" + r.aliases[requestedPath]
+		if !existing[remapping] {
+			remappings = append(remappings, remapping)
+		}
+	}
+	input.Settings.Remappings = remappings
+}
+
 // resolveFileImports resolves imports for a specific file
 func (r *importResolver) resolveFileImports(input *Input, fileName string, depth int) error {
 	if depth > r.maxDepth {
-		return fmt.Errorf("maximum import depth exceeded for file: %s", fileName)
+		return fmt.Errorf("maximum import depth of %d exceeded for file: %s, set CompileOptions.MaxImportDepth to raise the limit", r.maxDepth, fileName)
 	}
 
 	if r.resolvedSources[fileName] {
@@ -73,29 +188,61 @@ func (r *importResolver) resolveFileImports(input *Input, fileName string, depth
 		return fmt.Errorf("failed to extract imports from %s: %w", fileName, err)
 	}
 
-	// Resolve each import
-	for _, importPath := range imports {
+	// Collect the imports missing from the sources, each requested once
+	resolvedPaths := make([]string, len(imports))
+	var candidates [][]string
+	var missing []string
+	scheduled := make(map[string]bool)
+	for i, importPath := range imports {
 		resolvedPath := r.resolveAbsolutePath(importPath, fileName)
+		if alias, exists := r.aliases[resolvedPath]; exists {
+			resolvedPath = alias
+		}
+		resolvedPaths[i] = resolvedPath
 
-		// Skip if already in sources
-		if _, exists := input.Sources[resolvedPath]; exists {
-			// Still need to recursively resolve this file's imports
-			if err := r.resolveFileImports(input, resolvedPath, depth+1); err != nil {
+		if _, exists := input.Sources[resolvedPath]; exists || scheduled[resolvedPath] || r.failed[resolvedPath] {
+			continue
+		}
+		scheduled[resolvedPath] = true
+
+		candidates = append(candidates, r.candidateURLs(resolvedPath))
+		missing = append(missing, resolvedPath)
+	}
+
+	// Fetch them through the callback and add them to the sources
+	results := r.fetch(candidates)
+	for i, result := range results {
+		resolvedPath := missing[i]
+		if result.Error != "" {
+			err := fmt.Errorf("failed to import %s: %s", resolvedPath, result.Error)
+			if r.failFast {
 				return err
 			}
+			r.errs = append(r.errs, err)
+			r.failed[resolvedPath] = true
 			continue
 		}
 
-		// Call the import callback to get the content
-		result := r.importCallback(resolvedPath)
-		if result.Error != "" {
-			return fmt.Errorf("import resolution failed for %s: %s", resolvedPath, result.Error)
+		// Key the source by the canonical path if the callback reported one
+		if result.ResolvedPath != "" && result.ResolvedPath != resolvedPath {
+			r.aliases[resolvedPath] = result.ResolvedPath
+			resolvedPath = result.ResolvedPath
 		}
 
-		// Add the resolved source to input
-		input.Sources[resolvedPath] = SourceIn{Content: result.Contents}
+		// Add the resolved source to input, keeping an already supplied one
+		if _, exists := input.Sources[resolvedPath]; !exists {
+			input.Sources[resolvedPath] = SourceIn{Content: result.Contents}
+		}
+	}
 
-		// Recursively resolve imports in the newly added file
+	// Recursively resolve the imports of every imported file
+	for _, resolvedPath := range resolvedPaths {
+		if alias, exists := r.aliases[resolvedPath]; exists {
+			resolvedPath = alias
+		}
+		if r.failed[resolvedPath] {
+			continue
+		}
 		if err := r.resolveFileImports(input, resolvedPath, depth+1); err != nil {
 			return err
 		}
@@ -104,40 +251,241 @@ func (r *importResolver) resolveFileImports(input *Input, fileName string, depth
 	return nil
 }
 
-// extractImports finds all import statements in Solidity source code
-func (r *importResolver) extractImports(sourceCode string) ([]string, error) {
-	// Regex pattern to match Solidity import statements
-	// Matches: import "path"; import {symbol} from "path"; import * as name from "path";
-	pattern := `import\s+(?:(?:\{[^}]*\}|\*\s+as\s+\w+|\w+)\s+from\s+)?["']([^"']+)["']`
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+// candidateURLs returns the URLs the callback is asked for, in order, to load
+// the source unit resolvedPath: joined to the base path, then to each include
+// path, like solc's --base-path and --include-path.
+func (r *importResolver) candidateURLs(resolvedPath string) []string {
+	url := resolvedPath
+	if r.basePath != "" {
+		url = path.Join(r.basePath, resolvedPath)
+	}
+	urls := []string{url}
+	for _, includePath := range r.includePaths {
+		if candidate := path.Join(includePath, resolvedPath); candidate != url {
+			urls = append(urls, candidate)
+		}
+	}
+	return urls
+}
+
+// fetch loads each import from its candidate URLs, with up to concurrency
+// callback calls in flight. With failFast, sequential fetching stops at the
+// first error, so the results can be fewer than the imports; results are in
+// the order of the imports either way.
+func (r *importResolver) fetch(candidates [][]string) []ImportResult {
+	if r.concurrency <= 1 || len(candidates) <= 1 {
+		results := make([]ImportResult, 0, len(candidates))
+		for _, urls := range candidates {
+			result, calls := r.load(urls)
+			r.callbackCount += calls
+			results = append(results, result)
+			if result.Error != "" && r.failFast {
+				break
+			}
+		}
+		return results
+	}
+
+	results := make([]ImportResult, len(candidates))
+	calls := make([]int, len(candidates))
+	slots := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i, urls := range candidates {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], calls[i] = r.load(urls)
+		}()
+	}
+	wg.Wait()
+	for _, n := range calls {
+		r.callbackCount += n
+	}
+	return results
+}
+
+// load calls the import callback with the candidate URLs of an import in
+// order until one resolves, and returns the result with the number of calls
+// made. If none resolves, the error for the first URL is returned together
+// with the searched URLs.
+func (r *importResolver) load(urls []string) (ImportResult, int) {
+	var first ImportResult
+	for i, url := range urls {
+		result := r.callWithTimeout(url)
+		if result.Error == "" {
+			return result, i + 1
+		}
+		if i == 0 {
+			first = result
+		}
+	}
+	if len(urls) > 1 {
+		first.Error = fmt.Sprintf("%s (searched %s)", first.Error, strings.Join(urls, ", "))
+	}
+	return first, len(urls)
+}
+
+// callWithTimeout calls the import callback, giving up after the timeout if
+// one is configured. A timed-out callback keeps running in the background,
+// but its result is discarded.
+func (r *importResolver) callWithTimeout(url string) ImportResult {
+	if r.timeout <= 0 {
+		return r.importCallback(url)
 	}
 
+	done := make(chan ImportResult, 1)
+	go func() {
+		done <- r.importCallback(url)
+	}()
+
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		return ImportResult{Error: fmt.Sprintf("import callback timed out after %v", r.timeout)}
+	}
+}
+
+// importPattern matches an import statement starting at the beginning of the text.
+// Matches: import "path"; import "path" as name; import {A as B, C} from "path";
+// import * as name from "path"; and the legacy import name as alias from "path";
+// An alias after the path needs no handling since the path is matched first.
+// Whitespace is optional where solc allows it, e.g. import"path"; or import{A}from"path";
+var importPattern = regexp.MustCompile(`^import\s*(?:(?:\{[^}]*\}\s*|\*\s*as\s+\w+\s+|\w+(?:\s+as\s+\w+)?\s+)from\s*)?["']([^"'\n]+)["']`)
+
+// extractImports finds all import statements in Solidity source code.
+// Comments are stripped first and string literals are skipped, so imports
+// that are commented out or quoted in strings are ignored.
+func (r *importResolver) extractImports(sourceCode string) ([]string, error) {
+	source := stripComments(sourceCode)
+
 	var imports []string
-	matches := re.FindAllStringSubmatch(sourceCode, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			imports = append(imports, match[1])
+	for i := 0; i < len(source); i++ {
+		switch {
+		case source[i] == '"' || source[i] == '\'':
+			i = skipStringLiteral(source, i)
+		case isKeywordAt(source, i, "import"):
+			if match := importPattern.FindStringSubmatch(source[i:]); match != nil {
+				imports = append(imports, match[1])
+				i += len(match[0]) - 1
+			}
 		}
 	}
 
 	return imports, nil
 }
 
-// resolveAbsolutePath converts a relative import path to an absolute path
+// skipStringLiteral returns the index of the quote closing the string literal
+// opened at start, honoring escapes. Unterminated literals end at the line end.
+func skipStringLiteral(source string, start int) int {
+	quote := source[start]
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i
+		}
+	}
+	return len(source)
+}
+
+// isKeywordAt reports whether the keyword starts at index i of source as a
+// whole word rather than as part of an identifier such as "importance".
+func isKeywordAt(source string, i int, keyword string) bool {
+	if !strings.HasPrefix(source[i:], keyword) {
+		return false
+	}
+	if i > 0 && isIdentifierByte(source[i-1]) {
+		return false
+	}
+	end := i + len(keyword)
+	return end == len(source) || !isIdentifierByte(source[end])
+}
+
+// isIdentifierByte reports whether c can be part of a Solidity identifier.
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// resolveAbsolutePath converts a relative import path to an absolute path.
+// Source unit names always use forward slashes, so this uses package path
+// rather than path/filepath, which would produce backslashes on Windows.
 func (r *importResolver) resolveAbsolutePath(importPath, currentFile string) string {
-	// If it's already absolute (doesn't start with . or ..), return as-is
-	if !strings.HasPrefix(importPath, ".") {
+	if !isRelativeImport(importPath) {
 		return importPath
 	}
 
-	// Get the directory of the current file
-	currentDir := filepath.Dir(currentFile)
+	// Resolve against the directory of the current file, cleaning . and ..
+	resolvedPath := path.Join(path.Dir(currentFile), importPath)
+
+	// Like solc, drop .. segments that would climb above the root instead of
+	// producing source names outside the tree
+	for strings.HasPrefix(resolvedPath, "../") {
+		resolvedPath = strings.TrimPrefix(resolvedPath, "../")
+	}
+	return resolvedPath
+}
+
+// isRelativeImport reports whether an import path is relative to the importing
+// file. Only paths starting with ./ or ../ are; anything else, including names
+// like ".hidden/Foo.sol", is a direct import used as-is.
+func isRelativeImport(importPath string) bool {
+	return strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../")
+}
+
+// findMissingImports returns the sorted import paths referenced by the input
+// sources that are not part of the sources, after applying the remappings
+// from the settings.
+func (r *importResolver) findMissingImports(input *Input) ([]string, error) {
+	missing := make(map[string]bool)
+	for fileName, source := range input.Sources {
+		imports, err := r.extractImports(source.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract imports from %s: %w", fileName, err)
+		}
+
+		for _, importPath := range imports {
+			resolvedPath := applyRemappings(r.resolveAbsolutePath(importPath, fileName), fileName, input.Settings.Remappings)
+			if _, exists := input.Sources[resolvedPath]; !exists {
+				missing[resolvedPath] = true
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(missing))
+	for missingPath := range missing {
+		paths = append(paths, missingPath)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
 
-	// Resolve the relative path
-	resolvedPath := filepath.Join(currentDir, importPath)
+// applyRemappings rewrites an import path using solc-style remappings of the
+// form "[context:]prefix=target". As in solc, the longest matching context wins,
+// then the longest matching prefix.
+func applyRemappings(importPath, currentFile string, remappings []string) string {
+	bestContext, bestPrefix := -1, -1
+	best := importPath
+
+	for _, remapping := range remappings {
+		context, rest := "", remapping
+		if i := strings.Index(remapping, ":"); i >= 0 && i < strings.Index(remapping, "=") {
+			context, rest = remapping[:i], remapping[i+1:]
+		}
+		prefix, target, ok := strings.Cut(rest, "=")
+		if !ok || !strings.HasPrefix(currentFile, context) || !strings.HasPrefix(importPath, prefix) {
+			continue
+		}
+		if len(context) > bestContext || (len(context) == bestContext && len(prefix) > bestPrefix) {
+			bestContext, bestPrefix = len(context), len(prefix)
+			best = target + strings.TrimPrefix(importPath, prefix)
+		}
+	}
 
-	// Clean the path to resolve .. and . components
-	return filepath.Clean(resolvedPath)
+	return best
 }
@@ -0,0 +1,715 @@
+package solc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestApplyRemappings(t *testing.T) {
+	remappings := []string{
+		"@oz/=lib/openzeppelin/",
+		"@oz/token/=lib/oz-token/",
+		"src/legacy:@oz/=lib/openzeppelin-v4/",
+	}
+
+	assert.Equal(t, "lib/openzeppelin/utils/Context.sol", applyRemappings("@oz/utils/Context.sol", "src/A.sol", remappings))
+	assert.Equal(t, "lib/oz-token/ERC20.sol", applyRemappings("@oz/token/ERC20.sol", "src/A.sol", remappings), "Longest prefix should win")
+	assert.Equal(t, "lib/openzeppelin-v4/token/ERC20.sol", applyRemappings("@oz/token/ERC20.sol", "src/legacy/B.sol", remappings), "Context should win over prefix length")
+	assert.Equal(t, "other/File.sol", applyRemappings("other/File.sol", "src/A.sol", remappings))
+}
+
+func TestMissingImportsWithoutCallback(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: contractWithMultipleImports},
+			"lib/Math.sol":   {Content: mathLibrary},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	}
+
+	// A clear error names the missing import
+	_, err = compiler.CompileWithOptions(input, nil)
+	require.ErrorIs(t, err, ErrImportFailed)
+	assert.Contains(t, err.Error(), "lib/String.sol")
+	assert.NotContains(t, err.Error(), "lib/Math.sol")
+
+	// Opting out leaves the error to solc
+	output, err := compiler.CompileWithOptions(input, &CompileOptions{AllowMissingImports: true})
+	require.NoError(t, err)
+	assert.True(t, output.HasErrors())
+
+	// Supplying the import makes the check pass
+	input.Sources["lib/String.sol"] = SourceIn{Content: stringLibrary}
+	output, err = compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	assert.False(t, output.HasErrors())
+}
+
+func TestImportResultResolvedPath(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: contractWithImport},
+			"sub/Other.sol": {Content: `pragma solidity ^0.8.0;
+import "../vendor/Math.sol";
+contract Other { function f() public pure returns (uint256) { return Math.add(1, 2); } }`},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	}
+
+	var requested []string
+	output, err := compiler.CompileWithOptions(input, &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			requested = append(requested, url)
+			return ImportResult{Contents: mathLibrary, ResolvedPath: "deps/Math.sol"}
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+
+	assert.ElementsMatch(t, []string{"lib/Math.sol", "vendor/Math.sol"}, requested)
+	assert.Contains(t, output.Sources, "deps/Math.sol", "Import should be keyed by the resolved path")
+	assert.NotContains(t, output.Sources, "lib/Math.sol")
+	assert.NotContains(t, output.Sources, "vendor/Math.sol")
+	assert.Len(t, output.Contracts["deps/Math.sol"], 1, "Library should only be compiled once")
+}
+
+func TestResolveAbsolutePath(t *testing.T) {
+	resolver := newImportResolver(nil)
+
+	tests := []struct {
+		importPath  string
+		currentFile string
+		expected    string
+	}{
+		{"./Math.sol", "Calculator.sol", "Math.sol"},
+		{"./lib/Math.sol", "contracts/Calculator.sol", "contracts/lib/Math.sol"},
+		{"../lib/Math.sol", "contracts/tokens/Token.sol", "contracts/lib/Math.sol"},
+		{"../../Math.sol", "a/b/c/D.sol", "a/Math.sol"},
+		{"./../lib/./Math.sol", "contracts/tokens/Token.sol", "contracts/lib/Math.sol"},
+		{"@openzeppelin/contracts/token/ERC20/ERC20.sol", "contracts/Token.sol", "@openzeppelin/contracts/token/ERC20/ERC20.sol"},
+		{"../../utils/Foo.sol", "contracts/token/erc20/Token.sol", "contracts/utils/Foo.sol"},
+		{"../../utils/Foo.sol", "contracts/token/Token.sol", "utils/Foo.sol"},
+		{"../../../utils/Foo.sol", "contracts/token/Token.sol", "utils/Foo.sol"},
+		{"../Foo.sol", "/project/Token.sol", "/Foo.sol"},
+		{".hidden/Foo.sol", "contracts/Token.sol", ".hidden/Foo.sol"},
+	}
+
+	for _, tt := range tests {
+		resolved := resolver.resolveAbsolutePath(tt.importPath, tt.currentFile)
+		assert.Equal(t, tt.expected, resolved, "%s from %s", tt.importPath, tt.currentFile)
+		assert.NotContains(t, resolved, `\`, "Source keys must use forward slashes on every platform")
+	}
+}
+
+func TestNestedRelativeImports(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	sources := map[string]SourceIn{
+		"contracts/token/erc20/Token.sol": {Content: `pragma solidity ^0.8.0;
+import "../../utils/Foo.sol";
+import "../../../lib/Bar.sol";
+import "../../../../lib/Baz.sol";
+contract Token is Foo, Bar, Baz {}`},
+		"contracts/utils/Foo.sol": {Content: `pragma solidity ^0.8.0; contract Foo {}`},
+		"lib/Bar.sol":             {Content: `pragma solidity ^0.8.0; contract Bar {}`},
+		"lib/Baz.sol":             {Content: `pragma solidity ^0.8.0; contract Baz {}`},
+	}
+	selection := map[string]map[string][]string{"*": {"*": []string{"abi"}}}
+
+	// The resolver agrees with solc's own resolution of the supplied sources
+	output, err := compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources:  sources,
+		Settings: Settings{OutputSelection: selection},
+	}, nil)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+
+	// Through the callback, the imports are requested by their resolved names
+	var requested []string
+	output, err = compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"contracts/token/erc20/Token.sol": sources["contracts/token/erc20/Token.sol"]},
+		Settings: Settings{OutputSelection: selection},
+	}, &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			requested = append(requested, url)
+			source, ok := sources[url]
+			if !ok {
+				return ImportResult{Error: "not found"}
+			}
+			return ImportResult{Contents: source.Content}
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	assert.ElementsMatch(t, []string{"contracts/utils/Foo.sol", "lib/Bar.sol", "lib/Baz.sol"}, requested)
+}
+
+func TestImportAliasForms(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	libraries := map[string]string{
+		"lib/Plain.sol":  `pragma solidity ^0.8.0; contract Plain {}`,
+		"lib/Unit.sol":   `pragma solidity ^0.8.0; contract Unit {}`,
+		"lib/Star.sol":   `pragma solidity ^0.8.0; contract Star {}`,
+		"lib/Symbol.sol": `pragma solidity ^0.8.0; contract A {} contract C {}`,
+	}
+
+	// Every official import form triggers the callback
+	var requested []string
+	output, err := compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{"Main.sol": {Content: `pragma solidity ^0.8.0;
+import "./lib/Plain.sol";
+import "./lib/Unit.sol" as UnitFile;
+import * as StarFile from "./lib/Star.sol";
+import {A as B, C} from "./lib/Symbol.sol";
+contract Main is Plain, UnitFile.Unit, StarFile.Star, B, C {}`}},
+		Settings: Settings{OutputSelection: map[string]map[string][]string{"*": {"*": []string{"abi"}}}},
+	}, &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			requested = append(requested, url)
+			content, ok := libraries[url]
+			if !ok {
+				return ImportResult{Error: "not found"}
+			}
+			return ImportResult{Contents: content}
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	assert.ElementsMatch(t, []string{"lib/Plain.sol", "lib/Unit.sol", "lib/Star.sol", "lib/Symbol.sol"}, requested)
+}
+
+// largeLibrary returns a library of at least size bytes whose comments are
+// full of characters that would break a JavaScript string or template
+// literal if the contents were interpolated into a script.
+func largeLibrary(size int) string {
+	var b strings.Builder
+	b.WriteString("// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\n\nlibrary Large {\n")
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "    /* `${injected}` \\ \"double\" 'single' </script> \u2028\u2029 %d */\n", i)
+		if i%100 == 0 {
+			fmt.Fprintf(&b, "    function f%d(uint256 x) internal pure returns (uint256) { return x + %d; }\n", i, i)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func TestLargeImportContents(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	library := largeLibrary(512 << 10)
+	output, err := compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{"Main.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+import "lib/Large.sol";
+contract Main { function g() external pure returns (uint256) { return Large.f0(1); } }`}},
+		Settings: Settings{OutputSelection: map[string]map[string][]string{"*": {"*": []string{"metadata"}}}},
+	}, &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			return ImportResult{Contents: library}
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, output.Errors)
+
+	// The compiler saw the contents byte for byte
+	var metadata struct {
+		Sources map[string]struct {
+			Keccak256 string `json:"keccak256"`
+		} `json:"sources"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output.Contracts["Main.sol"]["Main"].Metadata), &metadata))
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(library))
+	assert.Equal(t, "0x"+hex.EncodeToString(h.Sum(nil)), metadata.Sources["lib/Large.sol"].Keccak256)
+}
+
+func TestImportTimeoutAndConcurrency(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{"Main.sol": {Content: `pragma solidity ^0.8.0;
+import "lib/A.sol";
+import "lib/B.sol";
+import "lib/C.sol";
+import "lib/D.sol";
+contract Main is A, B, C, D {}`}},
+		Settings: Settings{OutputSelection: map[string]map[string][]string{"*": {"*": []string{"abi"}}}},
+	}
+
+	// A slow callback: every import takes a while
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	slowCallback := func(delay time.Duration) ImportCallback {
+		return func(url string) ImportResult {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			time.Sleep(delay)
+			name := strings.TrimSuffix(path.Base(url), ".sol")
+			return ImportResult{Contents: fmt.Sprintf("pragma solidity ^0.8.0; contract %s {}", name)}
+		}
+	}
+
+	// The imports of a file are fetched in parallel, up to the limit
+	stats := &CompileStats{}
+	output, err := compiler.CompileWithOptions(input.Clone(), &CompileOptions{
+		ImportCallback:    slowCallback(100 * time.Millisecond),
+		ImportConcurrency: 2,
+		Stats:             stats,
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	assert.Equal(t, 2, maxInFlight)
+	assert.Equal(t, 4, stats.ImportsResolved)
+
+	// Sequential by default
+	maxInFlight = 0
+	_, err = compiler.CompileWithOptions(input.Clone(), &CompileOptions{ImportCallback: slowCallback(time.Millisecond)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, maxInFlight)
+
+	// A stalled import fails with a clear error instead of hanging
+	start := time.Now()
+	_, err = compiler.CompileWithOptions(input.Clone(), &CompileOptions{
+		ImportCallback: slowCallback(time.Minute),
+		ImportTimeout:  50 * time.Millisecond,
+	})
+	require.ErrorIs(t, err, ErrImportFailed)
+	assert.Contains(t, err.Error(), "failed to import lib/A.sol: import callback timed out after 50ms")
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestImportFailuresJoined(t *testing.T) {
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Main.sol": {Content: `pragma solidity ^0.8.0; import "./A.sol"; import "./Missing1.sol"; contract Main {}`},
+		},
+	}
+	var calls int
+	callback := func(url string) ImportResult {
+		calls++
+		if url == "A.sol" {
+			return ImportResult{Contents: `pragma solidity ^0.8.0; import "./Missing1.sol"; import "./Missing2.sol"; contract A {}`}
+		}
+		return ImportResult{Error: "File not found"}
+	}
+
+	// Every failure is reported at once, each failed import requested once
+	_, _, err := resolveInputCopy(input, callback, nil)
+	require.ErrorIs(t, err, ErrImportFailed)
+	assert.Contains(t, err.Error(), "failed to import Missing1.sol: File not found")
+	assert.Contains(t, err.Error(), "failed to import Missing2.sol: File not found")
+	assert.Equal(t, 2, strings.Count(err.Error(), "failed to import"))
+	assert.Equal(t, 3, calls)
+
+	// ImportFailFast keeps aborting at the first failure
+	calls = 0
+	_, _, err = resolveInputCopy(input, callback, &CompileOptions{ImportFailFast: true})
+	require.ErrorIs(t, err, ErrImportFailed)
+	assert.Contains(t, err.Error(), "failed to import Missing1.sol: File not found")
+	assert.NotContains(t, err.Error(), "Missing2.sol")
+	assert.Equal(t, 2, calls)
+}
+
+func TestBasePath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	files := map[string]string{
+		"contracts/lib/Math.sol":              `pragma solidity ^0.8.0; import "./Util.sol"; library Math {}`,
+		"contracts/lib/Util.sol":              `pragma solidity ^0.8.0; library Util {}`,
+		"node_modules/@oz/access/Ownable.sol": `pragma solidity ^0.8.0; contract Ownable {}`,
+	}
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Main.sol": {Content: `pragma solidity ^0.8.0;
+import "./lib/Math.sol";
+import "@oz/access/Ownable.sol";
+contract Main is Ownable {}`},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{"*": {"*": []string{"abi"}}},
+		},
+	}
+	var requested []string
+	options := &CompileOptions{
+		BasePath:     "contracts",
+		IncludePaths: []string{"node_modules"},
+		ImportCallback: func(url string) ImportResult {
+			requested = append(requested, url)
+			content, ok := files[url]
+			if !ok {
+				return ImportResult{Error: "not found"}
+			}
+			return ImportResult{Contents: content}
+		},
+	}
+
+	cachedInput := input.Clone()
+	output, err := compiler.CompileWithOptions(input, options)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+
+	// Every import is loaded from the base path first, then the include paths
+	expected := []string{"contracts/lib/Math.sol", "contracts/lib/Util.sol", "contracts/@oz/access/Ownable.sol", "node_modules/@oz/access/Ownable.sol"}
+	assert.ElementsMatch(t, expected, requested)
+
+	// The sources keep the names solc resolved
+	assert.Contains(t, output.Contracts, "lib/Math.sol")
+	assert.Contains(t, output.Contracts, "lib/Util.sol")
+	assert.NotContains(t, output.Contracts, "contracts/lib/Math.sol")
+
+	// Cached compilation resolves imports the same way
+	requested = nil
+	cached, err := CompileCached(compiler, cachedInput, options)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, requested)
+	assert.Equal(t, output.Contracts, cached.Contracts)
+}
+
+func TestIncludePaths(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"contracts/lib/Util.sol":                `pragma solidity ^0.8.0; library Util {}`,
+		"node_modules/@acme/math/Math.sol":      `pragma solidity ^0.8.0; import "./Round.sol"; library Math {}`,
+		"node_modules/@acme/math/Round.sol":     `pragma solidity ^0.8.0; library Round {}`,
+		"vendor/@acme/access/Ownable.sol":       `pragma solidity ^0.8.0; contract Ownable {}`,
+		"node_modules/@acme/access/Ownable.sol": `this shadowed copy must not be loaded`,
+	}
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Main.sol": {Content: `pragma solidity ^0.8.0;
+import "./lib/Util.sol";
+import "@acme/math/Math.sol";
+import "@acme/access/Ownable.sol";
+contract Main is Ownable {}`},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{"*": {"*": []string{"abi"}}},
+		},
+	}
+
+	// Without a callback the files are read from disk, searching the include
+	// paths in order
+	options := &CompileOptions{
+		BasePath:     filepath.Join(root, "contracts"),
+		IncludePaths: []string{filepath.Join(root, "vendor"), filepath.Join(root, "node_modules")},
+	}
+	output, err := compiler.CompileWithOptions(input.Clone(), options)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	for _, name := range []string{"lib/Util.sol", "@acme/math/Math.sol", "@acme/math/Round.sol", "@acme/access/Ownable.sol"} {
+		assert.Contains(t, output.Contracts, name)
+	}
+
+	// A file found nowhere lists every searched location
+	missing := input.Clone()
+	missing.Sources["Main.sol"] = SourceIn{Content: `pragma solidity ^0.8.0; import "@acme/missing/Lib.sol";`}
+	_, err = compiler.CompileWithOptions(missing, options)
+	require.ErrorIs(t, err, ErrImportFailed)
+	slashRoot := filepath.ToSlash(root)
+	assert.Contains(t, err.Error(), fmt.Sprintf("failed to import @acme/missing/Lib.sol: File not found (searched %s, %s, %s)",
+		path.Join(slashRoot, "contracts/@acme/missing/Lib.sol"),
+		path.Join(slashRoot, "vendor/@acme/missing/Lib.sol"),
+		path.Join(slashRoot, "node_modules/@acme/missing/Lib.sol")))
+
+	// Files outside the base and include paths cannot be read
+	secret := filepath.Join(filepath.Dir(root), filepath.Base(root)+"-secret.sol")
+	require.NoError(t, os.WriteFile(secret, []byte(`pragma solidity ^0.8.0; contract Secret {}`), 0644))
+	t.Cleanup(func() { os.Remove(secret) })
+	escaping := input.Clone()
+	escaping.Sources["Main.sol"] = SourceIn{Content: `pragma solidity ^0.8.0; import "` + filepath.ToSlash(secret) + `";`}
+	_, err = compiler.CompileWithOptions(escaping, &CompileOptions{IncludePaths: options.IncludePaths})
+	require.ErrorIs(t, err, ErrImportFailed)
+	assert.Contains(t, err.Error(), "File outside of allowed directories")
+
+	// Climbing imports stay within the base path, as solc normalizes them
+	escaping.Sources["Main.sol"] = SourceIn{Content: `pragma solidity ^0.8.0; import "../../` + filepath.Base(secret) + `";`}
+	_, err = compiler.CompileWithOptions(escaping, options)
+	require.ErrorIs(t, err, ErrImportFailed)
+	assert.Contains(t, err.Error(), "File not found")
+}
+
+func TestResolveImportGraph(t *testing.T) {
+	files := map[string]string{
+		"lib/Math.sol":   mathLibrary,
+		"lib/String.sol": stringLibrary,
+		"lib/Base.sol":   "pragma solidity ^0.8.0;\nimport \"./Math.sol\";\ncontract Base {}",
+	}
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: contractWithMultipleImports},
+			"Token.sol":      {Content: "pragma solidity ^0.8.0;\nimport \"./lib/Base.sol\";\ncontract Token is Base {}"},
+		},
+	}
+
+	var requested []string
+	sources, err := ResolveImportGraph(input, func(url string) ImportResult {
+		requested = append(requested, url)
+		content, exists := files[url]
+		if !exists {
+			return ImportResult{Error: "not found"}
+		}
+		return ImportResult{Contents: content}
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"Calculator.sol", "Token.sol", "lib/Math.sol", "lib/String.sol", "lib/Base.sol"}, keys(sources))
+	assert.Equal(t, mathLibrary, sources["lib/Math.sol"].Content)
+	assert.Len(t, requested, 3, "Each file should be fetched once")
+	assert.Len(t, input.Sources, 2, "Input should be left unchanged")
+
+	// Without a callback, missing imports are reported
+	_, err = ResolveImportGraph(input, nil)
+	assert.ErrorContains(t, err, "no import callback configured")
+}
+
+func TestMaxImportDepth(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	// A chain of 60 files, each importing the next
+	const chainLength = 60
+	callback := func(url string) ImportResult {
+		var n int
+		if _, err := fmt.Sscanf(url, "chain/File%d.sol", &n); err != nil {
+			return ImportResult{Error: "not found"}
+		}
+		content := fmt.Sprintf("pragma solidity ^0.8.0;\ncontract File%d {}\n", n)
+		if n < chainLength {
+			content = fmt.Sprintf("pragma solidity ^0.8.0;\nimport \"./File%d.sol\";\ncontract File%d {}\n", n+1, n)
+		}
+		return ImportResult{Contents: content}
+	}
+	newInput := func() *Input {
+		return &Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Root.sol": {Content: "pragma solidity ^0.8.0;\nimport \"./chain/File1.sol\";\ncontract Root {}\n"},
+			},
+			Settings: Settings{
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"abi"}},
+				},
+			},
+		}
+	}
+
+	_, err = compiler.CompileWithOptions(newInput(), &CompileOptions{ImportCallback: callback})
+	assert.ErrorContains(t, err, "maximum import depth of 50 exceeded")
+	assert.ErrorContains(t, err, "MaxImportDepth")
+
+	output, err := compiler.CompileWithOptions(newInput(), &CompileOptions{ImportCallback: callback, MaxImportDepth: 100})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+	assert.Len(t, output.Sources, chainLength+1)
+}
+
+const abiEncoderV2Contract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+pragma experimental ABIEncoderV2;
+
+import "./lib/Math.sol";
+// import "./lib/Commented.sol";
+/* import "./lib/BlockCommented.sol"; */
+
+contract Encoder {
+    struct Point { uint256 x; uint256 y; }
+
+    function sum(Point memory p) public pure returns (uint256) {
+        return Math.add(p.x, p.y);
+    }
+}
+`
+
+const abicoderV1Contract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+pragma abicoder v1;
+
+import "./lib/Math.sol";
+
+contract Legacy {
+    function add(uint256 a, uint256 b) public pure returns (uint256) {
+        return Math.add(a, b);
+    }
+}
+`
+
+func TestExperimentalPragmas(t *testing.T) {
+	resolver := newImportResolver(nil)
+	imports, err := resolver.extractImports(abiEncoderV2Contract)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./lib/Math.sol"}, imports, "Pragmas and commented-out imports should be ignored")
+
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	for name, source := range map[string]string{"Encoder.sol": abiEncoderV2Contract, "Legacy.sol": abicoderV1Contract} {
+		var requested []string
+		output, err := compiler.CompileWithOptions(&Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				name: {Content: source},
+			},
+			Settings: Settings{
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"abi", "evm.bytecode.object"}},
+				},
+			},
+		}, &CompileOptions{
+			ImportCallback: func(url string) ImportResult {
+				requested = append(requested, url)
+				return ImportResult{Contents: mathLibrary}
+			},
+		})
+		require.NoError(t, err, name)
+		require.False(t, output.HasErrors(), output.Errors)
+		assert.Equal(t, []string{"lib/Math.sol"}, requested, name)
+
+		for _, contract := range output.Contracts[name] {
+			assert.NotEmpty(t, contract.EVM.Bytecode.Object, name)
+		}
+	}
+}
+
+// importStyles is a corpus of real-world import statements and the paths
+// they import
+var importStyles = []struct {
+	source   string
+	expected []string
+}{
+	{`import "./Math.sol";`, []string{"./Math.sol"}},
+	{`import './Math.sol';`, []string{"./Math.sol"}},
+	{`import "@openzeppelin/contracts/token/ERC20/ERC20.sol";`, []string{"@openzeppelin/contracts/token/ERC20/ERC20.sol"}},
+	{`import {ERC20} from "./ERC20.sol";`, []string{"./ERC20.sol"}},
+	{"import {\n    IERC20,\n    IERC20Metadata\n} from \"./IERC20.sol\";", []string{"./IERC20.sol"}},
+	{`import * as Utils from "./Utils.sol";`, []string{"./Utils.sol"}},
+	{`import Utils from "./Utils.sol";`, []string{"./Utils.sol"}},
+	{`import "./Aliased.sol" as Aliased;`, []string{"./Aliased.sol"}},
+	{`import './Aliased.sol'as Aliased;`, []string{"./Aliased.sol"}},
+	{`import {A as B, C} from "./Symbols.sol";`, []string{"./Symbols.sol"}},
+	{"import {\n    A as B,\n    C as D\n} from \"./Symbols.sol\";", []string{"./Symbols.sol"}},
+	{`import*as Utils from"./Utils.sol";`, []string{"./Utils.sol"}},
+	{`import Utils as U from "./Utils.sol";`, []string{"./Utils.sol"}},
+	{`import"./Tight.sol";`, []string{"./Tight.sol"}},
+	{`import{A}from"./Tight.sol";`, []string{"./Tight.sol"}},
+	{"import\t\"./Tab.sol\"  ;", []string{"./Tab.sol"}},
+	{`// import "./Commented.sol";`, nil},
+	{`/* import "./Block.sol"; */ import "./Real.sol";`, []string{"./Real.sol"}},
+	{`string constant s = "import './Quoted.sol';";`, nil},
+	{`string constant s = 'say "import \"./Escaped.sol\""'; import "./After.sol";`, []string{"./After.sol"}},
+	{`uint256 importance; function reimport() {}`, nil},
+	{`uint256 importance from "./NotAnImport.sol";`, nil},
+	{`contract A { event imported(string path); }`, nil},
+	{"import \"./A.sol\";\nimport \"./B.sol\";", []string{"./A.sol", "./B.sol"}},
+	{"import \"./Unterminated.sol", nil},
+	{"import \"./Broken\nLine.sol\";", nil},
+}
+
+func TestExtractImportStyles(t *testing.T) {
+	resolver := newImportResolver(nil)
+	for _, tt := range importStyles {
+		imports, err := resolver.extractImports(tt.source)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, imports, tt.source)
+	}
+}
+
+func FuzzExtractImports(f *testing.F) {
+	for _, tt := range importStyles {
+		f.Add(tt.source)
+	}
+	f.Add(contractWithMultipleImports)
+	f.Add(abiEncoderV2Contract)
+	f.Add(`import {A as B, C} from "./X.sol"; import "{" as X;`)
+
+	resolver := newImportResolver(nil)
+	f.Fuzz(func(t *testing.T, source string) {
+		imports, err := resolver.extractImports(source)
+		if err != nil {
+			t.Fatalf("extractImports failed: %v", err)
+		}
+		for _, importPath := range imports {
+			if importPath == "" || strings.ContainsAny(importPath, "\"'\n") {
+				t.Fatalf("invalid import path %q extracted from %q", importPath, source)
+			}
+			if !strings.Contains(source, importPath) {
+				t.Fatalf("import path %q is not part of %q", importPath, source)
+			}
+		}
+		if len(imports) > strings.Count(source, "import") {
+			t.Fatalf("more imports than import keywords in %q: %q", source, imports)
+		}
+	})
+}
@@ -1,9 +1,70 @@
 package solc
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type Input struct {
-	Language string              `json:"language,omitempty"`
-	Sources  map[string]SourceIn `json:"sources,omitempty"`
-	Settings Settings            `json:"settings,omitempty"`
+	Language       string              `json:"language,omitempty"`
+	Sources        map[string]SourceIn `json:"sources,omitempty"`
+	Settings       Settings            `json:"settings,omitempty"`
+	AuxiliaryInput *AuxiliaryInput     `json:"auxiliaryInput,omitempty"`
+
+	// Extra holds top-level standard JSON fields not modeled above. They are
+	// passed through to the compiler as-is, so forward-compatible inputs are
+	// not dropped. Modeled fields take precedence over Extra keys.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// inputFields is Input without its methods, used to avoid recursive marshalling
+type inputFields Input
+
+// MarshalJSON encodes the input, merging in the Extra fields.
+func (i Input) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(inputFields(i))
+	if err != nil || len(i.Extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range i.Extra {
+		if _, exists := fields[key]; !exists {
+			fields[key] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes the input, collecting unknown fields into Extra.
+func (i *Input) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*inputFields)(i)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, known := range []string{"language", "sources", "settings", "auxiliaryInput"} {
+		delete(fields, known)
+	}
+	if len(fields) > 0 {
+		i.Extra = fields
+	} else {
+		i.Extra = nil
+	}
+	return nil
+}
+
+// AuxiliaryInput carries additional data for the compiler, such as responses
+// to SMT queries requested in a previous compilation.
+type AuxiliaryInput struct {
+	// SMTLib2Responses maps query hashes to solver responses.
+	SMTLib2Responses map[string]string `json:"smtlib2responses,omitempty"`
 }
 
 type SourceIn struct {
@@ -12,13 +73,287 @@ type SourceIn struct {
 }
 
 type Settings struct {
-	Remappings      []string                       `json:"remappings,omitempty"`
-	Optimizer       Optimizer                      `json:"optimizer,omitempty"`
-	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	// Remappings are resolved natively by the compiler against the supplied
+	// sources, e.g. "@openzeppelin/=lib/openzeppelin-contracts/".
+	Remappings []string  `json:"remappings,omitempty"`
+	Optimizer  Optimizer `json:"optimizer,omitempty"`
+	// EVMVersion is the EVM version to target, e.g. "paris". When empty it
+	// is omitted from the standard JSON, so solc uses its own default (see
+	// Solc.DefaultEVMVersion); an empty string is never sent.
+	EVMVersion string `json:"evmVersion,omitempty"`
+	// ViaIR enables the IR-based code generator (solc >= 0.7.5).
+	ViaIR bool `json:"viaIR,omitempty"`
+	// EOFVersion selects EVM Object Format code generation (solc >= 0.8.29),
+	// e.g. 1 together with an EOF-enabled EVMVersion such as "osaka". EOF
+	// requires ViaIR. Leave nil for legacy bytecode.
+	EOFVersion      *int                           `json:"eofVersion,omitempty"`
 	OutputSelection map[string]map[string][]string `json:"outputSelection,omitempty"`
+	// StopAfter stops compilation after the given stage. Only "parsing" is
+	// supported (solc >= 0.7.3). No default output selection is added to
+	// inputs that set it.
+	StopAfter    string        `json:"stopAfter,omitempty"`
+	ModelChecker *ModelChecker `json:"modelChecker,omitempty"`
+	Metadata     *Metadata     `json:"metadata,omitempty"`
+	Debug        *Debug        `json:"debug,omitempty"`
+}
+
+// Debug controls debugging information in the generated code.
+type Debug struct {
+	// RevertStrings is one of "default", "strip", "debug" or "verboseDebug".
+	// "strip" removes revert reason strings to reduce the bytecode size.
+	RevertStrings string `json:"revertStrings,omitempty"`
+	// DebugInfo selects the debug annotations in the IR and assembly output,
+	// e.g. "location" and "snippet", or "*" for all.
+	DebugInfo []string `json:"debugInfo,omitempty"`
 }
 
 type Optimizer struct {
 	Enabled bool `json:"enabled,omitempty"`
-	Runs    int  `json:"runs,omitempty"`
+	// Runs is the number of times the code is expected to run, trading
+	// deployment cost (low values, down to 0) against runtime cost (high
+	// values, e.g. 2**32-1). It is only sent to solc when the optimizer is
+	// enabled, and must not be negative. Zero counts as unset and is omitted,
+	// so solc applies its default of 200, unless ExplicitRuns is set.
+	Runs int `json:"runs,omitempty"`
+	// ExplicitRuns sends Runs even when it is zero, to optimize purely for
+	// deployment cost. Decoding sets it for an explicit "runs": 0.
+	ExplicitRuns bool              `json:"-"`
+	Details      *OptimizerDetails `json:"details,omitempty"`
+}
+
+// optimizerFields is the JSON layout of Optimizer, with Runs present only
+// when it is set.
+type optimizerFields struct {
+	Enabled bool              `json:"enabled,omitempty"`
+	Runs    *int              `json:"runs,omitempty"`
+	Details *OptimizerDetails `json:"details,omitempty"`
+}
+
+// MarshalJSON encodes the optimizer settings. Runs is omitted when the
+// optimizer is disabled, or when it is zero and ExplicitRuns is not set.
+func (o Optimizer) MarshalJSON() ([]byte, error) {
+	if o.Runs < 0 {
+		return nil, fmt.Errorf("optimizer runs must not be negative, got %d", o.Runs)
+	}
+	fields := optimizerFields{Enabled: o.Enabled, Details: o.Details}
+	if o.Enabled && (o.Runs != 0 || o.ExplicitRuns) {
+		fields.Runs = &o.Runs
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes the optimizer settings, setting ExplicitRuns if runs
+// is present but zero.
+func (o *Optimizer) UnmarshalJSON(data []byte) error {
+	var fields optimizerFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*o = Optimizer{Enabled: fields.Enabled, Details: fields.Details}
+	if fields.Runs != nil {
+		o.Runs = *fields.Runs
+		o.ExplicitRuns = o.Runs == 0
+	}
+	return nil
+}
+
+// OptimizerDetails switches individual optimizer components on or off. Nil
+// fields are omitted, so solc applies its defaults for them.
+type OptimizerDetails struct {
+	// Peephole enables the peephole optimizer.
+	Peephole *bool `json:"peephole,omitempty"`
+	// Inliner enables the inliner of the legacy code generator (solc >= 0.8.5).
+	Inliner *bool `json:"inliner,omitempty"`
+	// JumpdestRemover removes unused JUMPDESTs.
+	JumpdestRemover *bool `json:"jumpdestRemover,omitempty"`
+	// OrderLiterals reorders literals in commutative operations.
+	OrderLiterals *bool `json:"orderLiterals,omitempty"`
+	// Deduplicate removes duplicate code blocks.
+	Deduplicate *bool `json:"deduplicate,omitempty"`
+	// CSE enables the common subexpression eliminator.
+	CSE *bool `json:"cse,omitempty"`
+	// ConstantOptimizer optimizes the representation of literal numbers and strings.
+	ConstantOptimizer *bool `json:"constantOptimizer,omitempty"`
+	// Yul enables the Yul optimizer.
+	Yul        *bool       `json:"yul,omitempty"`
+	YulDetails *YulDetails `json:"yulDetails,omitempty"`
+}
+
+// YulDetails tunes the Yul optimizer.
+type YulDetails struct {
+	// StackAllocation tries to free up stack slots by allocating variables.
+	StackAllocation bool `json:"stackAllocation,omitempty"`
+	// OptimizerSteps is the optimization step sequence, e.g. "dhfoDgvulfnTUtnIf".
+	OptimizerSteps string `json:"optimizerSteps,omitempty"`
+}
+
+// Metadata controls the contract metadata and the CBOR trailer appended to the bytecode.
+type Metadata struct {
+	// AppendCBOR appends the CBOR-encoded metadata to the bytecode (default true).
+	AppendCBOR *bool `json:"appendCBOR,omitempty"`
+	// UseLiteralContent embeds the source contents in the metadata instead of
+	// only their hashes and URLs. This makes the metadata self-contained for
+	// verification at the cost of its size; the bytecode hash changes too.
+	UseLiteralContent bool `json:"useLiteralContent,omitempty"`
+	// BytecodeHash is one of "ipfs" (default), "bzzr1" or "none".
+	BytecodeHash string `json:"bytecodeHash,omitempty"`
+}
+
+// ModelChecker configures the SMTChecker formal verification engines.
+type ModelChecker struct {
+	// Contracts selects the contracts to verify, keyed by source unit.
+	Contracts map[string][]string `json:"contracts,omitempty"`
+	// DivModNoSlacks encodes division and modulo without slack variables.
+	DivModNoSlacks bool `json:"divModNoSlacks,omitempty"`
+	// Engine is one of "all", "bmc", "chc" or "none".
+	Engine string `json:"engine,omitempty"`
+	// ExtCalls is either "trusted" or "untrusted".
+	ExtCalls string `json:"extCalls,omitempty"`
+	// Invariants lists the invariant kinds to report ("contract", "reentrancy").
+	Invariants        []string `json:"invariants,omitempty"`
+	ShowProvedSafe    bool     `json:"showProvedSafe,omitempty"`
+	ShowUnproved      bool     `json:"showUnproved,omitempty"`
+	ShowUnsupported   bool     `json:"showUnsupported,omitempty"`
+	BMCLoopIterations int      `json:"bmcLoopIterations,omitempty"`
+	// Solvers lists the solvers to use ("cvc4", "eld", "smtlib2", "z3").
+	Solvers []string `json:"solvers,omitempty"`
+	// Targets lists the verification targets (e.g. "assert", "overflow").
+	Targets []string `json:"targets,omitempty"`
+	// Timeout bounds each solver query in milliseconds. Queries that run out
+	// of time are reported as unproved warnings instead of failing the
+	// compilation. Zero leaves the queries unbounded.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Clone returns a deep copy of the input, so the copy can be modified without
+// affecting the original.
+func (i *Input) Clone() *Input {
+	if i == nil {
+		return nil
+	}
+
+	clone := *i
+	if i.Sources != nil {
+		clone.Sources = make(map[string]SourceIn, len(i.Sources))
+		for name, source := range i.Sources {
+			clone.Sources[name] = source
+		}
+	}
+	clone.Settings = i.Settings.clone()
+	if i.AuxiliaryInput != nil {
+		clone.AuxiliaryInput = &AuxiliaryInput{SMTLib2Responses: cloneStringMap(i.AuxiliaryInput.SMTLib2Responses)}
+	}
+	if i.Extra != nil {
+		clone.Extra = make(map[string]json.RawMessage, len(i.Extra))
+		for key, value := range i.Extra {
+			clone.Extra[key] = append(json.RawMessage(nil), value...)
+		}
+	}
+	return &clone
+}
+
+// WithOptimizerRuns returns a deep copy of the input with the optimizer runs
+// set to runs, e.g. for gas optimization sweeps. The Enabled flag of the
+// optimizer is left as is, and runs, including zero, are only sent to solc if
+// it is set.
+func (i *Input) WithOptimizerRuns(runs int) *Input {
+	clone := i.Clone()
+	if clone == nil {
+		clone = &Input{}
+	}
+	clone.Settings.Optimizer.Runs = runs
+	clone.Settings.Optimizer.ExplicitRuns = true
+	return clone
+}
+
+// clone returns a deep copy of the settings.
+func (s Settings) clone() Settings {
+	clone := s
+	clone.Remappings = cloneStrings(s.Remappings)
+	clone.Optimizer.Details = s.Optimizer.Details.clone()
+	clone.EOFVersion = clonePointer(s.EOFVersion)
+	if s.OutputSelection != nil {
+		clone.OutputSelection = make(map[string]map[string][]string, len(s.OutputSelection))
+		for file, contracts := range s.OutputSelection {
+			clone.OutputSelection[file] = cloneStringSliceMap(contracts)
+		}
+	}
+	if s.ModelChecker != nil {
+		modelChecker := *s.ModelChecker
+		modelChecker.Contracts = cloneStringSliceMap(s.ModelChecker.Contracts)
+		modelChecker.Invariants = cloneStrings(s.ModelChecker.Invariants)
+		modelChecker.Solvers = cloneStrings(s.ModelChecker.Solvers)
+		modelChecker.Targets = cloneStrings(s.ModelChecker.Targets)
+		clone.ModelChecker = &modelChecker
+	}
+	if s.Metadata != nil {
+		metadata := *s.Metadata
+		metadata.AppendCBOR = clonePointer(s.Metadata.AppendCBOR)
+		clone.Metadata = &metadata
+	}
+	if s.Debug != nil {
+		debug := *s.Debug
+		debug.DebugInfo = cloneStrings(s.Debug.DebugInfo)
+		clone.Debug = &debug
+	}
+	return clone
+}
+
+// clone returns a deep copy of the optimizer details.
+func (d *OptimizerDetails) clone() *OptimizerDetails {
+	if d == nil {
+		return nil
+	}
+	clone := *d
+	clone.Peephole = clonePointer(d.Peephole)
+	clone.Inliner = clonePointer(d.Inliner)
+	clone.JumpdestRemover = clonePointer(d.JumpdestRemover)
+	clone.OrderLiterals = clonePointer(d.OrderLiterals)
+	clone.Deduplicate = clonePointer(d.Deduplicate)
+	clone.CSE = clonePointer(d.CSE)
+	clone.ConstantOptimizer = clonePointer(d.ConstantOptimizer)
+	clone.Yul = clonePointer(d.Yul)
+	clone.YulDetails = clonePointer(d.YulDetails)
+	return &clone
+}
+
+// clonePointer returns a pointer to a copy of the value p points to.
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// cloneStrings returns a copy of the slice, preserving nil.
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+// cloneStringMap returns a copy of the map, preserving nil.
+func cloneStringMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(values))
+	for key, value := range values {
+		clone[key] = value
+	}
+	return clone
+}
+
+// cloneStringSliceMap returns a deep copy of the map, preserving nil.
+func cloneStringSliceMap(values map[string][]string) map[string][]string {
+	if values == nil {
+		return nil
+	}
+	clone := make(map[string][]string, len(values))
+	for key, value := range values {
+		clone[key] = cloneStrings(value)
+	}
+	return clone
 }
@@ -0,0 +1,518 @@
+package solc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const assertContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Checked {
+    function f(uint256 x) public pure {
+        assert(x > 0);
+    }
+}
+`
+
+func TestModelChecker(t *testing.T) {
+	// With the smtlib2 solver, the CHC engine hands its queries back to the
+	// caller instead of solving them in-process.
+	output := compileInput(t, "0.8.21", &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Checked.sol": {Content: assertContract},
+		},
+		Settings: Settings{
+			ModelChecker: &ModelChecker{
+				Engine:  "chc",
+				Targets: []string{"assert"},
+				Solvers: []string{"smtlib2"},
+				Timeout: 1000,
+			},
+		},
+	})
+
+	for _, e := range output.Errors {
+		assert.NotEqual(t, "error", e.Severity, e.FormattedMessage)
+	}
+	require.NotNil(t, output.AuxiliaryInputRequested, "CHC engine should request SMT queries")
+	assert.NotEmpty(t, output.AuxiliaryInputRequested.SMTLib2Queries)
+}
+
+func TestModelCheckerZ3(t *testing.T) {
+	// The bundled z3 solver needs the performance polyfill to run
+	output := compileInput(t, "0.8.21", &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Checked.sol": {Content: assertContract},
+		},
+		Settings: Settings{
+			ModelChecker: &ModelChecker{
+				Engine:  "chc",
+				Targets: []string{"assert"},
+				Solvers: []string{"z3"},
+			},
+		},
+	})
+
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	require.NotEmpty(t, output.Errors)
+	assert.Contains(t, output.Errors[0].Message, "Counterexample", "The solver should find the violation for x = 0")
+}
+
+func TestModelCheckerTimeout(t *testing.T) {
+	modelChecker := &ModelChecker{
+		Engine:       "all",
+		Targets:      []string{"assert"},
+		Solvers:      []string{"smtlib2"},
+		ShowUnproved: true,
+		Timeout:      1,
+	}
+	data, err := json.Marshal(Settings{ModelChecker: modelChecker})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"timeout":1`)
+
+	// The checker gives up on the queries it cannot answer in time and
+	// reports them as warnings, rather than hanging the compilation
+	done := make(chan *Output, 1)
+	go func() {
+		done <- compileInput(t, "0.8.21", &Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Checked.sol": {Content: assertContract},
+			},
+			Settings: Settings{ModelChecker: modelChecker},
+		})
+	}()
+
+	select {
+	case output := <-done:
+		assert.False(t, output.HasErrors(), "A short timeout should not fail the compilation")
+		var warnings int
+		for _, e := range output.Errors {
+			if e.IsWarning() {
+				warnings++
+			}
+		}
+		assert.NotZero(t, warnings, "Unproved targets should be reported as warnings")
+	case <-time.After(time.Minute):
+		t.Fatal("Model checker did not give up within the timeout")
+	}
+}
+
+func TestMetadataBytecodeHash(t *testing.T) {
+	compile := func(metadata *Metadata) string {
+		output := compileInput(t, "0.8.21", &Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Greeter.sol": {Content: constructorContract},
+			},
+			Settings: Settings{
+				Metadata: metadata,
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"evm.deployedBytecode.object"}},
+				},
+			},
+		})
+		require.Empty(t, output.Errors)
+		return output.Contracts["Greeter.sol"]["NoConstructor"].EVM.DeployedBytecode.Object
+	}
+
+	// By default the trailer holds the IPFS hash and the compiler version
+	defaultBytecode := compile(nil)
+	assert.Contains(t, defaultBytecode, "a264697066735822", "Default trailer should contain an ipfs hash")
+
+	// With bytecodeHash none only the compiler version remains in the trailer
+	noHash := compile(&Metadata{BytecodeHash: "none"})
+	assert.NotContains(t, noHash, "64697066735822", "Trailer should not contain an ipfs hash")
+	assert.Contains(t, noHash, "a164736f6c6343", "Trailer should only contain the solc version")
+
+	// Disabling appendCBOR removes the trailer entirely
+	appendCBOR := false
+	noCBOR := compile(&Metadata{BytecodeHash: "none", AppendCBOR: &appendCBOR})
+	assert.NotContains(t, noCBOR, "736f6c6343", "Bytecode should not have a CBOR trailer")
+	assert.Less(t, len(noCBOR), len(noHash))
+}
+
+func TestMetadataLiteralContent(t *testing.T) {
+	compile := func(metadata *Metadata) string {
+		output := compileInput(t, "0.8.21", &Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Greeter.sol": {Content: constructorContract},
+			},
+			Settings: Settings{
+				Metadata: metadata,
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"metadata"}},
+				},
+			},
+		})
+		require.Empty(t, output.Errors)
+		return output.Contracts["Greeter.sol"]["Greeter"].Metadata
+	}
+	sources := func(metadataJSON string) map[string]map[string]any {
+		var metadata struct {
+			Sources map[string]map[string]any `json:"sources"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(metadataJSON), &metadata))
+		return metadata.Sources
+	}
+
+	// By default sources are referenced by hash and URLs only
+	hashed := compile(nil)
+	assert.NotContains(t, sources(hashed)["Greeter.sol"], "content")
+	assert.Contains(t, sources(hashed)["Greeter.sol"], "urls")
+
+	// With literal content the metadata carries the full source
+	literal := compile(&Metadata{UseLiteralContent: true})
+	assert.Equal(t, constructorContract, sources(literal)["Greeter.sol"]["content"])
+	assert.Greater(t, len(literal), len(hashed)+len(constructorContract)/2)
+}
+
+func TestEmptyEVMVersionOmitted(t *testing.T) {
+	data, err := json.Marshal(Settings{EVMVersion: ""})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "evmVersion", "An empty EVM version should not be sent")
+
+	data, err = json.Marshal(Settings{EVMVersion: "paris"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"evmVersion":"paris"`)
+
+	// solc falls back to its default EVM version
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Checked.sol": {Content: assertContract},
+	}, "metadata")
+	require.Empty(t, output.Errors)
+	var metadata struct {
+		Settings struct {
+			EVMVersion string `json:"evmVersion"`
+		} `json:"settings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output.Contracts["Checked.sol"]["Checked"].Metadata), &metadata))
+	assert.Equal(t, defaultEVMVersion("0.8.21"), metadata.Settings.EVMVersion)
+}
+
+func TestInputExtraFields(t *testing.T) {
+	raw := `{"language":"Solidity","sources":{"A.sol":{"content":"contract A {}"}},"settings":{"optimizer":{}},"futureField":{"enabled":true}}`
+
+	var input Input
+	require.NoError(t, json.Unmarshal([]byte(raw), &input))
+	assert.Equal(t, "Solidity", input.Language)
+	require.Contains(t, input.Extra, "futureField")
+	assert.JSONEq(t, `{"enabled":true}`, string(input.Extra["futureField"]))
+
+	// Unknown fields round-trip through marshalling
+	data, err := json.Marshal(input)
+	require.NoError(t, err)
+	assert.JSONEq(t, raw, string(data))
+
+	// Modeled fields win over Extra keys
+	input.Extra["language"] = json.RawMessage(`"Yul"`)
+	data, err = json.Marshal(&input)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"language":"Solidity"`)
+
+	// Inputs without extra fields marshal as before
+	data, err = json.Marshal(Input{Language: "Solidity"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"language":"Solidity","settings":{"optimizer":{}}}`, string(data))
+}
+
+func TestRemappings(t *testing.T) {
+	output := compileInput(t, "0.8.21", &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: `
+pragma solidity ^0.8.0;
+
+import "@math/Math.sol";
+
+contract Calculator {
+    function add(uint256 a, uint256 b) public pure returns (uint256) {
+        return Math.add(a, b);
+    }
+}
+`},
+			"lib/math/src/Math.sol": {Content: mathLibrary},
+		},
+		Settings: Settings{
+			Remappings: []string{"@math/=lib/math/src/"},
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	})
+
+	assert.False(t, output.HasErrors(), "Remapped import should resolve without a callback")
+	assert.Contains(t, output.Contracts, "Calculator.sol")
+	assert.Contains(t, output.Contracts, "lib/math/src/Math.sol")
+}
+
+func TestOptimizerYulDetails(t *testing.T) {
+	yul := true
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+		},
+		Settings: Settings{
+			Optimizer: Optimizer{
+				Enabled: true,
+				Runs:    200,
+				Details: &OptimizerDetails{
+					Yul: &yul,
+					YulDetails: &YulDetails{
+						StackAllocation: true,
+						OptimizerSteps:  "dhfoDgvulfnTUtnIf",
+					},
+				},
+			},
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"evm.bytecode.object"}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(input.Settings.Optimizer)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true,"runs":200,"details":{"yul":true,"yulDetails":{"stackAllocation":true,"optimizerSteps":"dhfoDgvulfnTUtnIf"}}}`, string(data))
+
+	output := compileInput(t, "0.8.21", input)
+	assert.Empty(t, output.Errors)
+	assert.NotEmpty(t, output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.Object)
+
+	// An invalid step sequence is rejected by the compiler
+	input.Settings.Optimizer.Details.YulDetails.OptimizerSteps = "not-a-step"
+	output = compileInput(t, "0.8.21", input)
+	assert.True(t, output.HasErrors())
+}
+
+func TestOptimizerComponentToggles(t *testing.T) {
+	enabled, disabled := true, false
+	details := &OptimizerDetails{
+		Inliner: &disabled,
+		CSE:     &enabled,
+	}
+
+	// Only the toggled components are serialized, false included
+	data, err := json.Marshal(details)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"inliner":false,"cse":true}`, string(data))
+
+	output := compileInput(t, "0.8.21", &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+		},
+		Settings: Settings{
+			Optimizer: Optimizer{Enabled: true, Runs: 200, Details: details},
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"metadata"}},
+			},
+		},
+	})
+	require.Empty(t, output.Errors)
+
+	// The compiler applied them, as recorded in the metadata
+	var metadata struct {
+		Settings struct {
+			Optimizer struct {
+				Details map[string]json.RawMessage `json:"details"`
+			} `json:"optimizer"`
+		} `json:"settings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output.Contracts["Greeter.sol"]["Greeter"].Metadata), &metadata))
+	assert.Equal(t, "false", string(metadata.Settings.Optimizer.Details["inliner"]))
+	assert.Equal(t, "true", string(metadata.Settings.Optimizer.Details["cse"]))
+}
+
+func TestEOFVersion(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.30")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	if !compiler.Supports(FeatureEOF) {
+		t.Skipf("EOF is not supported by %s", compiler.ShortVersion())
+	}
+
+	eofVersion := 1
+	output, err := compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+		},
+		Settings: Settings{
+			EVMVersion: "osaka",
+			ViaIR:      true,
+			EOFVersion: &eofVersion,
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"evm.bytecode.object", "evm.deployedBytecode.object"}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+
+	evm := output.Contracts["Greeter.sol"]["Greeter"].EVM
+	assert.True(t, evm.Bytecode.IsEOF(), "Creation code should be an EOF container")
+	assert.True(t, evm.DeployedBytecode.IsEOF(), "Runtime code should be an EOF container")
+	_, err = hex.DecodeString(evm.Bytecode.Object)
+	assert.NoError(t, err)
+
+	// Legacy code generation is unaffected
+	output = compileSources(t, "0.8.30", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "evm.bytecode.object")
+	assert.False(t, output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.IsEOF())
+}
+
+func TestOptimizerRunsMarshalling(t *testing.T) {
+	// Runs are omitted when the optimizer is disabled
+	data, err := json.Marshal(Optimizer{Enabled: false, Runs: 200})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "runs")
+
+	// and when unset, so solc applies its default
+	data, err = json.Marshal(Optimizer{Enabled: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true}`, string(data))
+
+	// and kept when it is enabled, including an explicit zero
+	data, err = json.Marshal(Optimizer{Enabled: true, Runs: 1})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true,"runs":1}`, string(data))
+	data, err = json.Marshal(Optimizer{Enabled: true, ExplicitRuns: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true,"runs":0}`, string(data))
+	data, err = json.Marshal(Settings{Optimizer: Optimizer{Enabled: true, Runs: 4294967295}})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"optimizer":{"enabled":true,"runs":4294967295}`)
+
+	_, err = json.Marshal(Optimizer{Enabled: true, Runs: -1})
+	assert.ErrorContains(t, err, "optimizer runs must not be negative")
+
+	// Decoding is unaffected
+	var optimizer Optimizer
+	require.NoError(t, json.Unmarshal([]byte(`{"enabled":true,"runs":1000}`), &optimizer))
+	assert.Equal(t, Optimizer{Enabled: true, Runs: 1000}, optimizer)
+
+	// An explicit zero survives a round trip
+	optimizer = Optimizer{}
+	require.NoError(t, json.Unmarshal([]byte(`{"enabled":true,"runs":0}`), &optimizer))
+	assert.Equal(t, Optimizer{Enabled: true, ExplicitRuns: true}, optimizer)
+	data, err = json.Marshal(optimizer)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true,"runs":0}`, string(data))
+}
+
+func TestInputWithOptimizerRuns(t *testing.T) {
+	yul, appendCBOR, eofVersion := true, false, 1
+	original := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+		},
+		Settings: Settings{
+			Remappings: []string{"@oz/=lib/oz/"},
+			Optimizer: Optimizer{
+				Enabled: true,
+				Runs:    200,
+				Details: &OptimizerDetails{Yul: &yul, YulDetails: &YulDetails{OptimizerSteps: "dhfoD"}},
+			},
+			EOFVersion: &eofVersion,
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+			ModelChecker: &ModelChecker{Targets: []string{"assert"}, Contracts: map[string][]string{"Greeter.sol": {"Greeter"}}},
+			Metadata:     &Metadata{AppendCBOR: &appendCBOR},
+		},
+		AuxiliaryInput: &AuxiliaryInput{SMTLib2Responses: map[string]string{"q": "sat"}},
+		Extra:          map[string]json.RawMessage{"future": json.RawMessage(`{"a":1}`)},
+	}
+	before, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	sweep := original.WithOptimizerRuns(10000)
+	assert.Equal(t, 10000, sweep.Settings.Optimizer.Runs)
+	assert.Equal(t, 200, original.Settings.Optimizer.Runs)
+
+	// A sweep down to zero sends zero rather than solc's default
+	data, err := json.Marshal(original.WithOptimizerRuns(0).Settings.Optimizer)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"runs":0`)
+
+	// Everything else is copied
+	sweep.Settings.Optimizer.Runs = original.Settings.Optimizer.Runs
+	after, err := json.Marshal(sweep)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+
+	// Mutating the copy leaves the original unchanged
+	sweep.Sources["New.sol"] = SourceIn{Content: "contract New {}"}
+	sweep.Settings.Remappings[0] = "changed"
+	*sweep.Settings.Optimizer.Details.Yul = false
+	sweep.Settings.Optimizer.Details.YulDetails.OptimizerSteps = "changed"
+	*sweep.Settings.EOFVersion = 2
+	sweep.Settings.OutputSelection["*"]["*"][0] = "changed"
+	sweep.Settings.ModelChecker.Targets[0] = "changed"
+	sweep.Settings.ModelChecker.Contracts["Greeter.sol"][0] = "changed"
+	*sweep.Settings.Metadata.AppendCBOR = true
+	sweep.AuxiliaryInput.SMTLib2Responses["q"] = "changed"
+	sweep.Extra["future"][1] = 'X'
+
+	unchanged, err := json.Marshal(original)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(unchanged))
+}
+
+const revertContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Guarded {
+    address public owner = msg.sender;
+
+    function withdraw(uint256 amount) public view {
+        require(msg.sender == owner, "Guarded: caller is not the owner of this contract");
+        require(amount > 0, "Guarded: amount must be greater than zero");
+    }
+}
+`
+
+func TestDebugRevertStrings(t *testing.T) {
+	compile := func(debug *Debug) string {
+		output := compileInput(t, "0.8.21", &Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Guarded.sol": {Content: revertContract},
+			},
+			Settings: Settings{
+				Debug: debug,
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"evm.deployedBytecode.object", "evm.assembly"}},
+				},
+			},
+		})
+		require.False(t, output.HasErrors(), output.Errors)
+		return output.Contracts["Guarded.sol"]["Guarded"].EVM.DeployedBytecode.Object
+	}
+
+	data, err := json.Marshal(Settings{Debug: &Debug{RevertStrings: "strip", DebugInfo: []string{"location"}}})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"debug":{"revertStrings":"strip","debugInfo":["location"]}`)
+
+	reason := hex.EncodeToString([]byte("Guarded: caller is not the owner"))
+	withStrings := compile(nil)
+	stripped := compile(&Debug{RevertStrings: "strip"})
+	assert.Contains(t, withStrings, reason)
+	assert.NotContains(t, stripped, reason, "Revert strings should be stripped")
+	assert.Less(t, len(stripped), len(withStrings), "Stripped bytecode should be smaller")
+}
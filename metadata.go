@@ -0,0 +1,182 @@
+package solc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MetadataHash returns the hash of the contract metadata embedded in the
+// CBOR trailer of the deployed bytecode, which verification services match
+// against on-chain code: the base58 CIDv0 ("Qm...") listed as "dweb:/ipfs/..."
+// in the metadata for "ipfs", or the hex-encoded Swarm hash for "bzzr0" and
+// "bzzr1". It fails if evm.deployedBytecode.object was not selected or the
+// bytecode carries no hash, e.g. with Metadata.BytecodeHash set to "none".
+func (c *Contract) MetadataHash() (string, error) {
+	object := strings.TrimPrefix(c.EVM.DeployedBytecode.Object, "0x")
+	if object == "" {
+		return "", fmt.Errorf("deployed bytecode is empty, make sure evm.deployedBytecode.object is selected and the contract is not abstract")
+	}
+
+	fields, err := decodeMetadataTrailer(object)
+	if err != nil {
+		return "", err
+	}
+	if hash, ok := fields["ipfs"].([]byte); ok {
+		return base58Encode(hash), nil
+	}
+	for _, key := range []string{"bzzr1", "bzzr0"} {
+		if hash, ok := fields[key].([]byte); ok {
+			return hex.EncodeToString(hash), nil
+		}
+	}
+	return "", fmt.Errorf("bytecode metadata contains no ipfs or bzzr hash")
+}
+
+// decodeMetadataTrailer decodes the CBOR map that solc appends to a hex
+// bytecode object, whose length is given by the last two bytes. Only the
+// trailer is decoded, so unlinked library placeholders elsewhere in the
+// object do not matter.
+func decodeMetadataTrailer(object string) (map[string]any, error) {
+	if len(object) < 4 {
+		return nil, fmt.Errorf("bytecode does not end with CBOR metadata")
+	}
+	lengthBytes, err := hex.DecodeString(object[len(object)-4:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode hex: %w", err)
+	}
+	length := int(lengthBytes[0])<<8 | int(lengthBytes[1])
+	start := len(object) - 4 - 2*length
+	if length == 0 || start < 0 {
+		return nil, fmt.Errorf("bytecode does not end with CBOR metadata")
+	}
+	trailer, err := hex.DecodeString(object[start : len(object)-4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode hex: %w", err)
+	}
+
+	decoder := cborDecoder{data: trailer}
+	fields, err := decoder.decodeMap()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CBOR metadata: %w", err)
+	}
+	if decoder.pos != len(trailer) {
+		return nil, fmt.Errorf("invalid CBOR metadata: %d trailing bytes", len(trailer)-decoder.pos)
+	}
+	return fields, nil
+}
+
+// cborDecoder decodes the subset of CBOR used by solc's metadata trailer: a
+// map with text keys and byte string, text string or boolean values.
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// decodeMap decodes a map with text keys.
+func (d *cborDecoder) decodeMap() (map[string]any, error) {
+	major, count, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, fmt.Errorf("expected a map, got major type %d", major)
+	}
+
+	fields := make(map[string]any, count)
+	for i := uint64(0); i < count; i++ {
+		key, err := d.decodeValue()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a text key, got %T", key)
+		}
+		if fields[name], err = d.decodeValue(); err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return fields, nil
+}
+
+// decodeValue decodes a byte string, text string or boolean.
+func (d *cborDecoder) decodeValue() (any, error) {
+	major, arg, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case 2, 3:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, fmt.Errorf("string of %d bytes exceeds the data", arg)
+		}
+		value := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == 3 {
+			return string(value), nil
+		}
+		return value, nil
+	case 7:
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		}
+		return nil, fmt.Errorf("unsupported simple value %d", arg)
+	}
+	return nil, fmt.Errorf("unsupported major type %d", major)
+}
+
+// header decodes the initial byte and argument of a CBOR data item.
+func (d *cborDecoder) header() (major byte, arg uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, fmt.Errorf("unexpected end of data")
+	}
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, fmt.Errorf("unsupported additional information %d", info)
+	}
+	size := 1 << (info - 24)
+	if size > len(d.data)-d.pos {
+		return 0, 0, fmt.Errorf("unexpected end of data")
+	}
+	for _, b := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(b)
+	}
+	d.pos += size
+	return major, arg, nil
+}
+
+// base58Alphabet is the Bitcoin alphabet used by IPFS CIDs.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes data like an IPFS CIDv0, with leading zero bytes as "1".
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
@@ -0,0 +1,85 @@
+package solc
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ipfsHash computes the CIDv0 of a file that fits into a single chunk, the
+// way solc hashes the metadata it embeds.
+func ipfsHash(content string) string {
+	field := func(tag byte, value []byte) []byte {
+		return append(binary.AppendUvarint([]byte{tag}, uint64(len(value))), value...)
+	}
+	unixfs := append([]byte{0x08, 0x02}, field(0x12, []byte(content))...)
+	unixfs = binary.AppendUvarint(append(unixfs, 0x18), uint64(len(content)))
+	digest := sha256.Sum256(field(0x0a, unixfs))
+	return base58Encode(append([]byte{0x12, 0x20}, digest[:]...))
+}
+
+func TestMetadataHash(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	compile := func(bytecodeHash string) *Contract {
+		output, err := compiler.CompileWithOptions(&Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Greeter.sol": {Content: constructorContract},
+			},
+			Settings: Settings{
+				Metadata: &Metadata{BytecodeHash: bytecodeHash},
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"metadata", "evm.deployedBytecode.object"}},
+				},
+			},
+		}, nil)
+		require.NoError(t, err)
+		require.False(t, output.HasErrors(), output.Errors)
+		greeter := output.Contracts["Greeter.sol"]["Greeter"]
+		return &greeter
+	}
+
+	// The IPFS hash is the CID of the metadata
+	greeter := compile("ipfs")
+	hash, err := greeter.MetadataHash()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "Qm"), hash)
+	assert.Equal(t, ipfsHash(greeter.Metadata), hash)
+
+	hash, err = compile("bzzr1").MetadataHash()
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	_, err = compile("none").MetadataHash()
+	assert.ErrorContains(t, err, "no ipfs or bzzr hash")
+
+	_, err = (&Contract{}).MetadataHash()
+	assert.ErrorContains(t, err, "evm.deployedBytecode.object")
+}
+
+func TestMetadataHashLegacyTrailer(t *testing.T) {
+	// solc 0.4 appends {"bzzr0": <hash>} only
+	swarm := "b6c7e7f8d3b35bd417a9223b0e39a483072b1349f2fd0a6ad9c8d1cd7c10e1b5"
+	contract := &Contract{}
+	contract.EVM.DeployedBytecode.Object = "6080604052600080fd00a165627a7a72305820" + swarm + "0029"
+	hash, err := contract.MetadataHash()
+	require.NoError(t, err)
+	assert.Equal(t, swarm, hash)
+
+	// Placeholders before the trailer do not matter
+	contract.EVM.DeployedBytecode.Object = "73__$1234567890abcdef1234567890abcdef12$__00a165627a7a72305820" + swarm + "0029"
+	hash, err = contract.MetadataHash()
+	require.NoError(t, err)
+	assert.Equal(t, swarm, hash)
+
+	contract.EVM.DeployedBytecode.Object = "6080604052600080fd"
+	_, err = contract.MetadataHash()
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package solc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MultiCompiler compiles inputs whose sources are pinned to different
+// compiler versions by their pragmas, creating and caching one compiler
+// instance per version.
+type MultiCompiler struct {
+	mu        sync.Mutex
+	compilers map[string]Solc
+}
+
+// NewMultiCompiler creates a new MultiCompiler without any compiler instances.
+func NewMultiCompiler() *MultiCompiler {
+	return &MultiCompiler{
+		compilers: make(map[string]Solc),
+	}
+}
+
+// compiler returns the cached compiler for a version, creating it if needed.
+func (m *MultiCompiler) compiler(version string) (Solc, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compilers == nil {
+		return nil, fmt.Errorf("multi compiler: %w", ErrClosed)
+	}
+	if compiler, exists := m.compilers[version]; exists {
+		return compiler, nil
+	}
+
+	compiler, err := NewWithVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	m.compilers[version] = compiler
+	return compiler, nil
+}
+
+// Compile groups the input sources by the compiler version satisfying their
+// pragmas and those of their imports, and compiles each group with the
+// matching compiler. Each group's input also contains the transitive imports
+// of its sources that are part of the input. Imports resolved through the
+// import callback are not considered when selecting versions. The outputs are
+// keyed by compiler version.
+func (m *MultiCompiler) Compile(input *Input, options *CompileOptions) (map[string]*Output, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input cannot be nil")
+	}
+
+	groups, err := groupSourcesByVersion(input.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]*Output, len(groups))
+	for version, sources := range groups {
+		compiler, err := m.compiler(version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create compiler %s: %w", version, err)
+		}
+
+		groupInput := *input
+		groupInput.Sources = sources
+		output, err := compiler.CompileWithOptions(&groupInput, options)
+		if err != nil {
+			return nil, fmt.Errorf("compilation with %s failed: %w", version, err)
+		}
+		outputs[version] = output
+	}
+
+	return outputs, nil
+}
+
+// Close releases all compiler instances.
+func (m *MultiCompiler) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, compiler := range m.compilers {
+		compiler.Close()
+	}
+	m.compilers = nil
+	return nil
+}
+
+// groupSourcesByVersion selects a compiler version for every source from the
+// pragmas of the source and its transitive imports, and groups the sources
+// (with their imports) by version.
+func groupSourcesByVersion(sources map[string]SourceIn) (map[string]map[string]SourceIn, error) {
+	resolver := newImportResolver(nil)
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make(map[string]map[string]SourceIn)
+	for _, name := range names {
+		closure, err := importClosure(resolver, sources, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var constraints []constraint
+		for _, file := range closure {
+			pragma, err := ParsePragma(sources[file].Content)
+			if errors.Is(err, ErrNoPragma) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid pragma in %s: %w", file, err)
+			}
+			c, err := parseConstraint(pragma)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pragma in %s: %w", file, err)
+			}
+			constraints = append(constraints, c)
+		}
+
+		version, err := resolveConstraints(constraints)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select compiler version for %s: %w", name, err)
+		}
+
+		if groups[version] == nil {
+			groups[version] = make(map[string]SourceIn)
+		}
+		for _, file := range closure {
+			groups[version][file] = sources[file]
+		}
+	}
+
+	return groups, nil
+}
+
+// importClosure returns name and all sources it transitively imports that are
+// present in sources.
+func importClosure(resolver *importResolver, sources map[string]SourceIn, name string) ([]string, error) {
+	visited := map[string]bool{name: true}
+	closure := []string{name}
+
+	for i := 0; i < len(closure); i++ {
+		file := closure[i]
+		imports, err := resolver.extractImports(sources[file].Content)
+		if err != nil {
+			return nil, err
+		}
+		for _, importPath := range imports {
+			resolved := resolver.resolveAbsolutePath(importPath, file)
+			if _, exists := sources[resolved]; exists && !visited[resolved] {
+				visited[resolved] = true
+				closure = append(closure, resolved)
+			}
+		}
+	}
+
+	return closure, nil
+}
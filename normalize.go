@@ -0,0 +1,55 @@
+package solc
+
+import (
+	"strings"
+)
+
+// utf8BOM is the UTF-8 encoded byte order mark
+const utf8BOM = "\uFEFF"
+
+// normalizeSources returns a copy of the input whose source contents have the
+// UTF-8 BOM stripped and/or line endings converted to LF. The original input
+// is left untouched.
+func normalizeSources(input *Input, stripBOM, normalizeLineEndings bool) *Input {
+	normalized := *input
+	normalized.Sources = make(map[string]SourceIn, len(input.Sources))
+
+	for name, source := range input.Sources {
+		if stripBOM {
+			source.Content = strings.TrimPrefix(source.Content, utf8BOM)
+		}
+		if normalizeLineEndings {
+			source.Content = strings.ReplaceAll(source.Content, "\r\n", "\n")
+			source.Content = strings.ReplaceAll(source.Content, "\r", "\n")
+		}
+		normalized.Sources[name] = source
+	}
+
+	return &normalized
+}
+
+// spdxTag marks the license identifier comment of a Solidity source
+const spdxTag = "SPDX-License-Identifier:"
+
+// addDefaultSPDX returns a copy of the input in which every source without an
+// SPDX license identifier starts with one for license. Non-Solidity inputs
+// and sources without inline content are returned unchanged.
+func addDefaultSPDX(input *Input, license string) *Input {
+	if input.Language != "" && input.Language != "Solidity" {
+		return input
+	}
+
+	withLicense := *input
+	withLicense.Sources = make(map[string]SourceIn, len(input.Sources))
+	for name, source := range input.Sources {
+		if source.Content != "" && !strings.Contains(source.Content, spdxTag) {
+			// Keep a byte order mark in front
+			content := strings.TrimPrefix(source.Content, utf8BOM)
+			bom := source.Content[:len(source.Content)-len(content)]
+			source.Content = bom + "// " + spdxTag + " " + license + "\n" + content
+		}
+		withLicense.Sources[name] = source
+	}
+
+	return &withLicense
+}
@@ -0,0 +1,81 @@
+package solc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSources(t *testing.T) {
+	input := &Input{
+		Sources: map[string]SourceIn{
+			"A.sol": {Content: "\uFEFFpragma solidity ^0.8.0;\r\ncontract A {}\r"},
+		},
+	}
+
+	normalized := normalizeSources(input, true, true)
+	assert.Equal(t, "pragma solidity ^0.8.0;\ncontract A {}\n", normalized.Sources["A.sol"].Content)
+	assert.Equal(t, "\uFEFFpragma solidity ^0.8.0;\r\ncontract A {}\r", input.Sources["A.sol"].Content, "Original input should not be modified")
+
+	bomOnly := normalizeSources(input, true, false)
+	assert.Equal(t, "pragma solidity ^0.8.0;\r\ncontract A {}\r", bomOnly.Sources["A.sol"].Content)
+}
+
+func TestCompileWithBOM(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Windows.sol": {Content: "\uFEFF// SPDX-License-Identifier: MIT\r\npragma solidity ^0.8.0;\r\n\r\ncontract Windows {}\r\n"},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	}
+
+	output, err := compiler.CompileWithOptions(input, &CompileOptions{StripBOM: true, NormalizeLineEndings: true})
+	require.NoError(t, err)
+	assert.False(t, output.HasErrors())
+	assert.Contains(t, output.Contracts["Windows.sol"], "Windows")
+}
+
+func TestDefaultSPDX(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	licensed := "// SPDX-License-Identifier: GPL-3.0\npragma solidity ^0.8.0;\ncontract Licensed {}\n"
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Generated.sol": {Content: "pragma solidity ^0.8.0;\ncontract Generated {}\n"},
+			"Licensed.sol":  {Content: licensed},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{"*": {"*": []string{"metadata"}}},
+		},
+	}
+
+	// Without the option solc warns about the missing identifiers
+	output, err := compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, output.Errors)
+
+	output, err = compiler.CompileWithOptions(input, &CompileOptions{DefaultSPDX: "MIT"})
+	require.NoError(t, err)
+	assert.Empty(t, output.Errors, "Injected identifiers should silence the warnings")
+	assert.Contains(t, output.Contracts["Generated.sol"]["Generated"].Metadata, `"license":"MIT"`)
+	assert.Contains(t, output.Contracts["Licensed.sol"]["Licensed"].Metadata, `"license":"GPL-3.0"`)
+
+	input.Sources["Bom.sol"] = SourceIn{Content: "\uFEFFpragma solidity ^0.8.0;\ncontract Bom {}\n"}
+	withLicense := addDefaultSPDX(input, "MIT")
+	assert.Equal(t, licensed, withLicense.Sources["Licensed.sol"].Content, "Sources with an identifier should not be touched")
+	assert.Equal(t, "\uFEFF// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\ncontract Bom {}\n", withLicense.Sources["Bom.sol"].Content, "A byte order mark should stay in front")
+	assert.Equal(t, "pragma solidity ^0.8.0;\ncontract Generated {}\n", input.Sources["Generated.sol"].Content, "Original input should not be modified")
+}
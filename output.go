@@ -1,13 +1,221 @@
 package solc
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type Output struct {
 	Errors    []Error                        `json:"errors,omitempty"`
 	Sources   map[string]SourceOut           `json:"sources,omitempty"`
 	Contracts map[string]map[string]Contract `json:"contracts,omitempty"`
+	// AuxiliaryInputRequested holds the SMT queries the compiler could not
+	// answer itself, when the SMTChecker is run with the "smtlib2" solver.
+	AuxiliaryInputRequested *AuxiliaryInputRequested `json:"auxiliaryInputRequested,omitempty"`
+}
+
+// ParseOutput parses a standard JSON output produced elsewhere, e.g. loaded
+// from disk, into an Output without recompiling. Hardhat build info files
+// carry such an output in their "output" field.
+func ParseOutput(jsonStr string) (*Output, error) {
+	output := &Output{}
+	if err := json.Unmarshal([]byte(jsonStr), output); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
+	}
+	return output, nil
+}
+
+// Range calls fn for every contract in the output, ordered by file and
+// contract name, until fn returns false.
+func (o *Output) Range(fn func(file, name string, c *Contract) bool) {
+	files := make([]string, 0, len(o.Contracts))
+	for file := range o.Contracts {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		names := make([]string, 0, len(o.Contracts[file]))
+		for name := range o.Contracts[file] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			c := o.Contracts[file][name]
+			if !fn(file, name, &c) {
+				return
+			}
+		}
+	}
+}
+
+// RangeContracts decodes a standard JSON output from r and calls fn for
+// every contract as soon as it is decoded, in the order of the JSON, until fn
+// returns false. Only one contract is held in memory at a time and all other
+// fields, such as errors and ASTs, are skipped, so large outputs can be
+// written out artifact by artifact.
+func RangeContracts(r io.Reader, fn func(file, name string, c *Contract) bool) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode output: %w", err)
+		}
+		if key != "contracts" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode output: %w", err)
+		}
+		if token == nil {
+			continue
+		}
+		if token != json.Delim('{') {
+			return fmt.Errorf("failed to decode output: contracts is not an object")
+		}
+		for dec.More() {
+			file, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("failed to decode output: %w", err)
+			}
+			if err := expectDelim(dec, '{'); err != nil {
+				return err
+			}
+			for dec.More() {
+				name, err := dec.Token()
+				if err != nil {
+					return fmt.Errorf("failed to decode output: %w", err)
+				}
+				var c Contract
+				if err := dec.Decode(&c); err != nil {
+					return fmt.Errorf("failed to decode contract %s:%s: %w", file, name, err)
+				}
+				if !fn(file.(string), name.(string), &c) {
+					return nil
+				}
+			}
+			if err := expectDelim(dec, '}'); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode output: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("failed to decode output: expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// skipValue reads and discards the next value, token by token, so that large
+// values are never buffered.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode output: %w", err)
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// HasErrors reports whether the output contains any diagnostic with error severity.
+func (o *Output) HasErrors() bool {
+	for _, e := range o.Errors {
+		if e.IsError() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether the output contains no contracts. Together with
+// HasErrors it tells apart a failed compilation (empty with errors) from an
+// output selection that selects no contract outputs (empty without errors).
+func (o *Output) IsEmpty() bool {
+	for _, contracts := range o.Contracts {
+		if len(contracts) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SourceIDs returns the numeric ID of every source, keyed by source unit
+// name, as referenced by source maps and the "src" fields of the AST. solc
+// numbers the sources in the lexicographic order of their names, and inputs
+// are marshalled with sorted keys, so the same set of sources always gets the
+// same IDs; adding or renaming a source can shift the IDs of the others.
+func (o *Output) SourceIDs() map[string]int {
+	ids := make(map[string]int, len(o.Sources))
+	for file, source := range o.Sources {
+		ids[file] = source.ID
+	}
+	return ids
+}
+
+// RequireBytecode returns the creation bytecode object of the given contract,
+// or a descriptive error when it is missing.
+//
+// An empty bytecode object means either that the contract failed to compile,
+// that evm.bytecode (or evm.bytecode.object) was not part of the output
+// selection, or that the contract is abstract or an interface.
+func (o *Output) RequireBytecode(file, contract string) (string, error) {
+	for _, e := range o.Errors {
+		if e.IsError() {
+			return "", fmt.Errorf("compilation failed: %s", e.Message)
+		}
+	}
+
+	c, exists := o.Contracts[file][contract]
+	if !exists {
+		return "", fmt.Errorf("contract %s not found in %s, check the source name and output selection", contract, file)
+	}
+	if c.EVM.Bytecode.Object == "" {
+		return "", fmt.Errorf("contract %s in %s has no bytecode, check that evm.bytecode.object is selected and the contract is not abstract", contract, file)
+	}
+
+	return c.EVM.Bytecode.Object, nil
+}
+
+type AuxiliaryInputRequested struct {
+	// SMTLib2Queries maps query hashes to SMT-LIB2 queries.
+	SMTLib2Queries map[string]string `json:"smtlib2queries,omitempty"`
 }
 
 type Error struct {
@@ -19,6 +227,34 @@ type Error struct {
 	FormattedMessage string         `json:"formattedMessage,omitempty"`
 }
 
+// internalErrorTypes are error types that indicate a bug in the compiler
+// rather than a problem with the compiled sources
+var internalErrorTypes = map[string]bool{
+	"InternalCompilerError":     true,
+	"CompilerError":             true,
+	"UnimplementedFeatureError": true,
+	"Exception":                 true,
+	"FatalError":                true,
+	"YulException":              true,
+	"SMTLogicException":         true,
+}
+
+// IsError reports whether the diagnostic has error severity.
+func (e Error) IsError() bool {
+	return e.Severity == "error"
+}
+
+// IsWarning reports whether the diagnostic has warning severity.
+func (e Error) IsWarning() bool {
+	return e.Severity == "warning"
+}
+
+// IsInternal reports whether the diagnostic indicates a compiler bug, which
+// should be reported upstream rather than fixed in the sources.
+func (e Error) IsInternal() bool {
+	return internalErrorTypes[e.Type]
+}
+
 type SourceLocation struct {
 	File  string `json:"file,omitempty"`
 	Start int    `json:"start,omitempty"`
@@ -36,31 +272,295 @@ type Contract struct {
 	Metadata string            `json:"metadata,omitempty"`
 	UserDoc  json.RawMessage   `json:"userdoc,omitempty"`
 	DevDoc   json.RawMessage   `json:"devdoc,omitempty"`
-	IR       string            `json:"ir,omitempty"`
-	// StorageLayout StorageLayout     `json:"storageLayout,omitempty"`
-	EVM   EVM   `json:"evm,omitempty"`
-	EWASM EWASM `json:"ewasm,omitempty"`
+	// IR is the Yul intermediate representation, set when the "ir" output is
+	// selected. IROptimized is the same after the Yul optimizer, selected by
+	// "irOptimized". Both can be selected without any bytecode output and
+	// require solc >= 0.6.0 (see FeatureIR); older compilers leave them empty.
+	IR          string `json:"ir,omitempty"`
+	IROptimized string `json:"irOptimized,omitempty"`
+	// StorageLayout is set when the storageLayout output is selected.
+	StorageLayout *StorageLayout `json:"storageLayout,omitempty"`
+	EVM           EVM            `json:"evm,omitempty"`
+	EWASM         EWASM          `json:"ewasm,omitempty"`
+}
+
+// StorageLayout describes where the state variables of a contract are stored.
+type StorageLayout struct {
+	Storage []StorageEntry         `json:"storage"`
+	Types   map[string]StorageType `json:"types"`
+}
+
+// StorageEntry is a state variable in the storage layout.
+type StorageEntry struct {
+	ASTID    int    `json:"astId"`
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"`
+	// Type is a key into StorageLayout.Types.
+	Type string `json:"type"`
+}
+
+// StorageType describes a type referenced by the storage layout.
+type StorageType struct {
+	Encoding      string `json:"encoding"`
+	Label         string `json:"label"`
+	NumberOfBytes string `json:"numberOfBytes"`
+	// Key and Value are set for mappings, Base for arrays and Members for structs.
+	Key     string         `json:"key,omitempty"`
+	Value   string         `json:"value,omitempty"`
+	Base    string         `json:"base,omitempty"`
+	Members []StorageEntry `json:"members,omitempty"`
+}
+
+// CreationBytecode returns the hex-decoded creation bytecode from
+// evm.bytecode.object, i.e. the data of a contract creation transaction
+// without constructor arguments.
+func (c *Contract) CreationBytecode() ([]byte, error) {
+	return decodeBytecode(c.EVM.Bytecode.Object, "evm.bytecode.object")
+}
+
+// RuntimeBytecode returns the hex-decoded runtime bytecode from
+// evm.deployedBytecode.object, i.e. the code stored on chain after deployment.
+func (c *Contract) RuntimeBytecode() ([]byte, error) {
+	return decodeBytecode(c.EVM.DeployedBytecode.Object, "evm.deployedBytecode.object")
+}
+
+// Contract size limits enforced by Ethereum mainnet
+const (
+	// MaxRuntimeSize is the maximum size in bytes of deployed code (EIP-170).
+	MaxRuntimeSize = 24576
+	// MaxInitCodeSize is the maximum size in bytes of creation code, including
+	// constructor arguments, since the Shanghai upgrade (EIP-3860).
+	MaxInitCodeSize = 2 * MaxRuntimeSize
+)
+
+// CreationSize returns the size in bytes of the creation bytecode, without
+// constructor arguments, or zero if evm.bytecode.object was not selected.
+// Unlinked library placeholders count as the addresses replacing them.
+func (c *Contract) CreationSize() int {
+	return bytecodeSize(c.EVM.Bytecode.Object)
+}
+
+// RuntimeSize returns the size in bytes of the runtime bytecode, or zero if
+// evm.deployedBytecode.object was not selected.
+func (c *Contract) RuntimeSize() int {
+	return bytecodeSize(c.EVM.DeployedBytecode.Object)
+}
+
+// ExceedsSizeLimit reports whether the contract cannot be deployed to
+// mainnet because its runtime code exceeds MaxRuntimeSize or its creation
+// code exceeds MaxInitCodeSize. Select both bytecode objects for a full check.
+func (c *Contract) ExceedsSizeLimit() bool {
+	return c.RuntimeSize() > MaxRuntimeSize || c.CreationSize() > MaxInitCodeSize
+}
+
+// bytecodeSize returns the number of bytes encoded by a hex bytecode object.
+func bytecodeSize(object string) int {
+	return len(strings.TrimPrefix(object, "0x")) / 2
+}
+
+// decodeBytecode hex-decodes a bytecode object, naming the output selection
+// in the error if it is missing.
+func decodeBytecode(object, selection string) ([]byte, error) {
+	object = strings.TrimPrefix(object, "0x")
+	if object == "" {
+		return nil, fmt.Errorf("bytecode is empty, make sure %s is selected and the contract is not abstract", selection)
+	}
+	if strings.Contains(object, "__") {
+		return nil, fmt.Errorf("bytecode contains unlinked library placeholders")
+	}
+	code, err := hex.DecodeString(object)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode hex: %w", err)
+	}
+	return code, nil
 }
 
 type EVM struct {
-	Assembly          string                       `json:"assembly,omitempty"`
-	LegacyAssembly    json.RawMessage              `json:"legacyAssembly,omitempty"`
-	Bytecode          Bytecode                     `json:"bytecode,omitempty"`
-	DeployedBytecode  Bytecode                     `json:"deployedBytecode,omitempty"`
-	MethodIdentifiers map[string]string            `json:"methodIdentifiers,omitempty"`
-	GasEstimates      map[string]map[string]string `json:"gasEstimates,omitempty"`
+	Assembly string `json:"assembly,omitempty"`
+	// LegacyAssembly is kept raw since its schema is undocumented and
+	// changes between versions; use ParsedLegacyAssembly to decode it.
+	LegacyAssembly    json.RawMessage   `json:"legacyAssembly,omitempty"`
+	Bytecode          Bytecode          `json:"bytecode,omitempty"`
+	DeployedBytecode  DeployedBytecode  `json:"deployedBytecode,omitempty"`
+	MethodIdentifiers map[string]string `json:"methodIdentifiers,omitempty"`
+	GasEstimates      GasEstimates      `json:"gasEstimates,omitempty"`
+}
+
+// ParsedLegacyAssembly decodes the evm.legacyAssembly output. It returns nil
+// if the output was not selected, and an error if its shape is not understood.
+func (e EVM) ParsedLegacyAssembly() (*LegacyAssembly, error) {
+	if len(e.LegacyAssembly) == 0 || string(e.LegacyAssembly) == "null" {
+		return nil, nil
+	}
+	var assembly LegacyAssembly
+	if err := json.Unmarshal(e.LegacyAssembly, &assembly); err != nil {
+		return nil, fmt.Errorf("failed to parse legacy assembly: %w", err)
+	}
+	return &assembly, nil
+}
+
+// GasEstimates holds the gas estimates of a contract as reported by solc:
+// "creation" maps "codeDepositCost", "executionCost" and "totalCost" to
+// their estimates, and "external" and "internal" map function signatures
+// and names to theirs. Estimates are decimal strings or "infinite" when
+// solc cannot bound them; the accessors parse them into numbers.
+type GasEstimates map[string]map[string]string
+
+// CreationTotal returns the estimated total cost of deploying the contract.
+func (g GasEstimates) CreationTotal() (int64, bool) {
+	return parseGasEstimate(g["creation"]["totalCost"])
+}
+
+// CreationExecution returns the estimated execution cost of the constructor.
+func (g GasEstimates) CreationExecution() (int64, bool) {
+	return parseGasEstimate(g["creation"]["executionCost"])
+}
+
+// CreationCodeDeposit returns the estimated cost of storing the runtime code.
+func (g GasEstimates) CreationCodeDeposit() (int64, bool) {
+	return parseGasEstimate(g["creation"]["codeDepositCost"])
+}
+
+// External returns the estimated cost of calling the external function with
+// the given signature, e.g. "transfer(address,uint256)".
+func (g GasEstimates) External(signature string) (int64, bool) {
+	return parseGasEstimate(g["external"][signature])
+}
+
+// Internal returns the estimated cost of the internal function with the
+// given name and parameter types, as keyed by solc, e.g. "_transfer(address,uint256)".
+func (g GasEstimates) Internal(name string) (int64, bool) {
+	return parseGasEstimate(g["internal"][name])
+}
+
+// parseGasEstimate parses a gas estimate, reporting false for "infinite",
+// missing and malformed estimates.
+func parseGasEstimate(estimate string) (int64, bool) {
+	gas, err := strconv.ParseInt(estimate, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return gas, true
 }
 
 type Bytecode struct {
-	Object         string                                `json:"object,omitempty"`
-	Opcodes        string                                `json:"opcodes,omitempty"`
-	SourceMap      string                                `json:"sourceMap,omitempty"`
-	LinkReferences map[string]map[string][]LinkReference `json:"linkReferences,omitempty"`
+	Object    string `json:"object,omitempty"`
+	Opcodes   string `json:"opcodes,omitempty"`
+	SourceMap string `json:"sourceMap,omitempty"`
+	// LinkReferences maps source files and library names to the placeholders
+	// that must be replaced by the library addresses when linking.
+	LinkReferences map[string]map[string][]LinkRef `json:"linkReferences,omitempty"`
+	// FunctionDebugData maps internal function names to their entry points
+	// and stack layout.
+	FunctionDebugData map[string]FunctionDebugData `json:"functionDebugData,omitempty"`
+	// GeneratedSources are the compiler-generated Yul sources referenced by
+	// the source map, e.g. "#utility.yul".
+	GeneratedSources []GeneratedSource `json:"generatedSources,omitempty"`
+}
+
+// FunctionDebugData describes an internal function in the bytecode.
+type FunctionDebugData struct {
+	// EntryPoint is the byte offset of the function, or nil if it was inlined.
+	EntryPoint *int `json:"entryPoint"`
+	// ID is the AST ID of the function definition, or nil for generated functions.
+	ID             *int `json:"id"`
+	ParameterSlots int  `json:"parameterSlots"`
+	ReturnSlots    int  `json:"returnSlots"`
+}
+
+// GeneratedSource is a source generated by the compiler during code generation.
+type GeneratedSource struct {
+	AST      json.RawMessage `json:"ast,omitempty"`
+	Contents string          `json:"contents"`
+	ID       int             `json:"id"`
+	Language string          `json:"language"`
+	Name     string          `json:"name"`
+}
+
+// IsEOF reports whether the bytecode is an EVM Object Format container, which
+// starts with the 0xEF00 magic.
+func (b Bytecode) IsEOF() bool {
+	return strings.HasPrefix(strings.TrimPrefix(b.Object, "0x"), "ef00")
+}
+
+// DeployedBytecode is the runtime bytecode output, which additionally carries
+// the positions at which immutable values are injected on deployment.
+type DeployedBytecode struct {
+	Bytecode
+	// ImmutableReferences maps AST IDs of immutable variables to their
+	// offsets in the runtime code.
+	ImmutableReferences map[string][]Offset `json:"immutableReferences,omitempty"`
+}
+
+// Offset identifies a byte range within the bytecode.
+type Offset struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// LinkRef is the byte range of a library address placeholder in the bytecode.
+type LinkRef struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+	// End is the offset just past the placeholder, Start+Length. It is set
+	// when decoding and not sent back to JSON.
+	//
+	// Deprecated: Use Start+Length. End will be removed in a future release.
+	End int `json:"-"`
+}
+
+// UnmarshalJSON decodes a link reference and fills in the deprecated End.
+func (r *LinkRef) UnmarshalJSON(data []byte) error {
+	// The alias has no UnmarshalJSON method, so this does not recurse
+	type linkRef LinkRef
+	var decoded linkRef
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = LinkRef(decoded)
+	r.End = r.Start + r.Length
+	return nil
+}
+
+// LinkReference is the former name of LinkRef.
+//
+// Deprecated: Use LinkRef.
+type LinkReference = LinkRef
+
+// UnlinkedLibraries returns the fully qualified names ("file:Library") of the
+// libraries referenced by the creation or runtime bytecode, sorted, i.e. the
+// libraries that must be deployed and linked before the contract.
+func (c *Contract) UnlinkedLibraries() []string {
+	seen := make(map[string]bool)
+	for _, references := range []map[string]map[string][]LinkRef{
+		c.EVM.Bytecode.LinkReferences,
+		c.EVM.DeployedBytecode.LinkReferences,
+	} {
+		for file, libraries := range references {
+			for library := range libraries {
+				seen[file+":"+library] = true
+			}
+		}
+	}
+
+	libraries := make([]string, 0, len(seen))
+	for library := range seen {
+		libraries = append(libraries, library)
+	}
+	sort.Strings(libraries)
+	return libraries
 }
 
-type LinkReference struct {
-	Start int `json:"start,omitempty"`
-	End   int `json:"end,omitempty"`
+// IsLinked reports whether the contract can be deployed as is: neither the
+// creation nor the runtime bytecode contains library address placeholders,
+// and there are no link references. A linker that fills in the placeholders
+// should clear the link references as well.
+func (c *Contract) IsLinked() bool {
+	return !strings.Contains(c.EVM.Bytecode.Object, "__") &&
+		!strings.Contains(c.EVM.DeployedBytecode.Object, "__") &&
+		len(c.UnlinkedLibraries()) == 0
 }
 
 type EWASM struct {
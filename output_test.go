@@ -0,0 +1,502 @@
+package solc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compileSources compiles the given sources with an embedded compiler version
+// and the given output selection for all contracts.
+func compileSources(t *testing.T, version string, sources map[string]SourceIn, selection ...string) *Output {
+	t.Helper()
+
+	return compileInput(t, version, &Input{
+		Language: "Solidity",
+		Sources:  sources,
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": selection},
+			},
+		},
+	})
+}
+
+// compileInput compiles the given input with an embedded compiler version.
+func compileInput(t *testing.T, version string, input *Input) *Output {
+	t.Helper()
+
+	compiler, err := NewWithVersion(version)
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	output, err := compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	require.NotNil(t, output)
+	return output
+}
+
+const immutableContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Immutable {
+    uint256 public immutable value;
+
+    constructor(uint256 _value) {
+        value = _value;
+    }
+}
+`
+
+func TestImmutableReferences(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Immutable.sol": {Content: immutableContract},
+	}, "evm.deployedBytecode.object", "evm.deployedBytecode.immutableReferences")
+	require.Empty(t, output.Errors)
+
+	refs := output.Contracts["Immutable.sol"]["Immutable"].EVM.DeployedBytecode.ImmutableReferences
+	require.NotEmpty(t, refs, "Should have immutable references")
+	for _, offsets := range refs {
+		require.NotEmpty(t, offsets)
+		for _, offset := range offsets {
+			assert.Greater(t, offset.Start, 0, "Offset should point into the runtime code")
+			assert.Equal(t, 32, offset.Length, "Immutable slots are 32 bytes long")
+		}
+	}
+}
+
+func TestRequireBytecode(t *testing.T) {
+	sources := map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}
+
+	// Bytecode is returned when selected
+	output := compileSources(t, "0.8.21", sources, "evm.bytecode.object")
+	bytecode, err := output.RequireBytecode("Greeter.sol", "Greeter")
+	require.NoError(t, err)
+	assert.NotEmpty(t, bytecode)
+
+	_, err = output.RequireBytecode("Greeter.sol", "Missing")
+	assert.ErrorContains(t, err, "not found")
+
+	// Missing selection is reported instead of returning an empty object
+	output = compileSources(t, "0.8.21", sources, "abi")
+	_, err = output.RequireBytecode("Greeter.sol", "Greeter")
+	assert.ErrorContains(t, err, "evm.bytecode.object")
+
+	// Compile errors take precedence
+	output = compileSources(t, "0.8.21", map[string]SourceIn{
+		"Broken.sol": {Content: "pragma solidity ^0.8.0; contract Broken { function f() public { undefined(); } }"},
+	}, "evm.bytecode.object")
+	assert.True(t, output.HasErrors())
+	_, err = output.RequireBytecode("Broken.sol", "Broken")
+	assert.ErrorContains(t, err, "compilation failed")
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		err      Error
+		isError  bool
+		warning  bool
+		internal bool
+	}{
+		{Error{Type: "TypeError", Severity: "error"}, true, false, false},
+		{Error{Type: "Warning", Severity: "warning"}, false, true, false},
+		{Error{Type: "InternalCompilerError", Severity: "error"}, true, false, true},
+		{Error{Type: "UnimplementedFeatureError", Severity: "error"}, true, false, true},
+		{Error{Type: "CompilerError", Severity: "error"}, true, false, true},
+		{Error{Type: "Exception", Severity: "error"}, true, false, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.isError, tt.err.IsError(), tt.err.Type)
+		assert.Equal(t, tt.warning, tt.err.IsWarning(), tt.err.Type)
+		assert.Equal(t, tt.internal, tt.err.IsInternal(), tt.err.Type)
+	}
+}
+
+func TestAssemblyOutput(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "evm.assembly", "evm.legacyAssembly")
+	require.False(t, output.HasErrors(), output.Errors)
+
+	evm := output.Contracts["Greeter.sol"]["Greeter"].EVM
+	assert.Contains(t, evm.Assembly, "mstore(0x40, 0x80)", "Assembly should be the textual listing")
+
+	legacy, err := evm.ParsedLegacyAssembly()
+	require.NoError(t, err)
+	require.NotNil(t, legacy)
+	assert.NotEmpty(t, legacy.Code)
+	assert.Contains(t, legacy.SourceList, "Greeter.sol")
+	require.Contains(t, legacy.SubAssemblies, "0", "Runtime code should be a sub-assembly")
+	runtime := legacy.SubAssemblies["0"]
+	assert.NotEmpty(t, runtime.Code)
+	assert.NotEmpty(t, runtime.AuxData, "Runtime code should carry the metadata")
+
+	// The typed assembly round-trips through JSON
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	var decoded LegacyAssembly
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *legacy, decoded)
+
+	// An unexpected shape only fails the accessor, not the whole output
+	var parsed Output
+	require.NoError(t, json.Unmarshal([]byte(`{"contracts":{"A.sol":{"A":{"evm":{"legacyAssembly":{".code":"unexpected"}}}}}}`), &parsed))
+	_, err = parsed.Contracts["A.sol"]["A"].EVM.ParsedLegacyAssembly()
+	assert.ErrorContains(t, err, "failed to parse legacy assembly")
+
+	legacy, err = EVM{}.ParsedLegacyAssembly()
+	require.NoError(t, err)
+	assert.Nil(t, legacy)
+}
+
+func TestDebugOutputs(t *testing.T) {
+	output := compileSources(t, "0.8.30", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "evm.bytecode.functionDebugData", "evm.deployedBytecode.generatedSources", "evm.deployedBytecode.functionDebugData", "evm.deployedBytecode.sourceMap")
+	require.False(t, output.HasErrors(), output.Errors)
+
+	deployed := output.Contracts["Greeter.sol"]["Greeter"].EVM.DeployedBytecode
+	require.NotEmpty(t, deployed.GeneratedSources, "ABI decoding helpers should be generated")
+	for _, source := range deployed.GeneratedSources {
+		assert.Equal(t, "Yul", source.Language)
+		assert.NotEmpty(t, source.Name)
+		assert.NotEmpty(t, source.Contents)
+		assert.NotEmpty(t, source.AST)
+	}
+
+	require.NotEmpty(t, deployed.FunctionDebugData)
+	hasEntryPoint := false
+	for name, data := range deployed.FunctionDebugData {
+		assert.NotEmpty(t, name)
+		if data.EntryPoint != nil {
+			hasEntryPoint = true
+			assert.Greater(t, *data.EntryPoint, 0)
+		}
+	}
+	assert.True(t, hasEntryPoint, "Some functions should have an entry point")
+
+	assert.NotEmpty(t, output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.FunctionDebugData)
+}
+
+func TestCreationAndRuntimeBytecode(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "evm.bytecode.object", "evm.deployedBytecode.object")
+	contract := output.Contracts["Greeter.sol"]["Greeter"]
+
+	creation, err := contract.CreationBytecode()
+	require.NoError(t, err)
+	runtime, err := contract.RuntimeBytecode()
+	require.NoError(t, err)
+	assert.Greater(t, len(creation), len(runtime), "Creation code should wrap the runtime code")
+	assert.True(t, bytes.Contains(creation, runtime), "Creation code should contain the runtime code")
+
+	// Missing selections are reported
+	output = compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "evm.bytecode.object")
+	contract = output.Contracts["Greeter.sol"]["Greeter"]
+	_, err = contract.RuntimeBytecode()
+	assert.ErrorContains(t, err, "evm.deployedBytecode.object")
+
+	// Malformed and unlinked objects are rejected
+	contract.EVM.Bytecode.Object = "6080zz"
+	_, err = contract.CreationBytecode()
+	assert.ErrorContains(t, err, "invalid bytecode hex")
+	contract.EVM.Bytecode.Object = "73__$1234567890abcdef1234567890abcdef12$__"
+	_, err = contract.CreationBytecode()
+	assert.ErrorContains(t, err, "unlinked")
+}
+
+func TestIROutput(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "ir", "irOptimized")
+	require.Empty(t, output.Errors)
+
+	contract := output.Contracts["Greeter.sol"]["Greeter"]
+	assert.Contains(t, contract.IR, "object \"Greeter_")
+	assert.Contains(t, contract.IROptimized, "object \"Greeter_")
+	assert.NotEqual(t, contract.IR, contract.IROptimized)
+	assert.Empty(t, contract.EVM.Bytecode.Object, "IR can be selected without bytecode")
+}
+
+func TestContractSizes(t *testing.T) {
+	large := fmt.Sprintf(`pragma solidity ^0.8.0;
+contract Large {
+    function data() external pure returns (string memory) {
+        return "%s";
+    }
+}`, strings.Repeat("x", MaxRuntimeSize))
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+		"Large.sol":   {Content: large},
+	}, "evm.bytecode.object", "evm.deployedBytecode.object")
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+
+	greeter := output.Contracts["Greeter.sol"]["Greeter"]
+	creation, err := greeter.CreationBytecode()
+	require.NoError(t, err)
+	runtime, err := greeter.RuntimeBytecode()
+	require.NoError(t, err)
+	assert.Equal(t, len(creation), greeter.CreationSize())
+	assert.Equal(t, len(runtime), greeter.RuntimeSize())
+	assert.False(t, greeter.ExceedsSizeLimit())
+
+	contract := output.Contracts["Large.sol"]["Large"]
+	assert.Greater(t, contract.RuntimeSize(), MaxRuntimeSize)
+	assert.True(t, contract.ExceedsSizeLimit())
+
+	// Placeholders count as addresses and unselected bytecode as empty
+	unlinked := Contract{}
+	unlinked.EVM.DeployedBytecode.Object = "73__$1234567890abcdef1234567890abcdef12$__"
+	assert.Equal(t, 21, unlinked.RuntimeSize())
+	assert.Zero(t, unlinked.CreationSize())
+}
+
+func TestOutputIsEmpty(t *testing.T) {
+	sources := map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}
+
+	// Successful compilation
+	output := compileSources(t, "0.8.21", sources, "abi")
+	assert.False(t, output.IsEmpty())
+	assert.False(t, output.HasErrors())
+
+	// Failed compilation: empty with errors
+	output = compileSources(t, "0.8.21", map[string]SourceIn{
+		"Broken.sol": {Content: "pragma solidity ^0.8.0; contract Broken {"},
+	}, "abi")
+	assert.True(t, output.IsEmpty())
+	assert.True(t, output.HasErrors())
+
+	// Selection problem: empty without errors
+	output = compileInput(t, "0.8.21", &Input{
+		Language: "Solidity",
+		Sources:  sources,
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"Other.sol": {"*": []string{"abi"}},
+			},
+		},
+	})
+	assert.True(t, output.IsEmpty())
+	assert.False(t, output.HasErrors())
+}
+
+func TestSourceIDs(t *testing.T) {
+	sources := map[string]SourceIn{
+		"b/Second.sol": {Content: "pragma solidity ^0.8.0; contract Second {}"},
+		"Zed.sol":      {Content: "pragma solidity ^0.8.0; contract Zed {}"},
+		"a/First.sol":  {Content: "pragma solidity ^0.8.0; contract First {}"},
+	}
+
+	// Sources are numbered by name, the same on every compilation
+	expected := map[string]int{"Zed.sol": 0, "a/First.sol": 1, "b/Second.sol": 2}
+	for i := 0; i < 2; i++ {
+		output := compileSources(t, "0.8.21", sources, "evm.bytecode.sourceMap")
+		assert.Equal(t, expected, output.SourceIDs())
+	}
+}
+
+func TestGasEstimates(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Counter.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract Counter {
+    uint256 public count;
+    string public label;
+
+    function increment() public { _add(1); }
+    function setLabel(string memory value) public { label = value; }
+    function _add(uint256 amount) internal { if (!_isZero(amount)) count += amount; }
+    function _isZero(uint256 value) internal pure returns (bool) { return value == 0; }
+}`},
+	}, "evm.gasEstimates")
+	require.False(t, output.HasErrors(), output.Errors)
+	gas := output.Contracts["Counter.sol"]["Counter"].EVM.GasEstimates
+
+	total, ok := gas.CreationTotal()
+	require.True(t, ok, gas["creation"])
+	deposit, ok := gas.CreationCodeDeposit()
+	require.True(t, ok)
+	execution, ok := gas.CreationExecution()
+	require.True(t, ok, "%v", gas["creation"])
+	assert.Equal(t, deposit+execution, total)
+
+	getter, ok := gas.External("count()")
+	require.True(t, ok, "%v", gas["external"])
+	assert.Greater(t, getter, int64(0))
+	_, ok = gas.Internal("_isZero(uint256)")
+	assert.True(t, ok, "%v", gas["internal"])
+
+	// Unbounded costs and unknown functions have no number, the raw strings
+	// stay available
+	assert.Equal(t, "infinite", gas["external"]["setLabel(string)"])
+	_, ok = gas.External("setLabel(string)")
+	assert.False(t, ok)
+	_, ok = gas.External("missing()")
+	assert.False(t, ok)
+}
+
+func TestParseOutput(t *testing.T) {
+	output, err := ParseOutput(`{
+		"errors": [{"severity": "warning", "type": "Warning", "message": "unused variable"}],
+		"sources": {"Greeter.sol": {"id": 0}},
+		"contracts": {"Greeter.sol": {"Greeter": {
+			"abi": [{"type": "event", "name": "Greeted", "inputs": [], "anonymous": false}],
+			"evm": {"bytecode": {"object": "6080"}, "deployedBytecode": {"object": "6001"}}
+		}}}
+	}`)
+	require.NoError(t, err)
+	assert.False(t, output.HasErrors())
+	require.Len(t, output.Errors, 1)
+	assert.True(t, output.Errors[0].IsWarning())
+
+	contract := output.Contracts["Greeter.sol"]["Greeter"]
+	assert.Equal(t, "Greeted()", contract.Events()[0].Signature())
+	creation, err := contract.CreationBytecode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x60, 0x80}, creation)
+
+	// Outputs from the compiler round-trip through their JSON form
+	compiled := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Greeter.sol": {Content: constructorContract},
+	}, "abi", "evm.bytecode.object")
+	data, err := json.Marshal(compiled)
+	require.NoError(t, err)
+	parsed, err := ParseOutput(string(data))
+	require.NoError(t, err)
+	assert.Equal(t, compiled, parsed)
+
+	_, err = ParseOutput("{not json")
+	assert.ErrorContains(t, err, "failed to unmarshal output")
+}
+
+const externalLibraryContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+library Pricing {
+    function price(uint256 amount) external pure returns (uint256) {
+        return amount * 2;
+    }
+}
+
+library Fees {
+    function fee(uint256 amount) external pure returns (uint256) {
+        return amount / 100;
+    }
+}
+
+contract Shop {
+    function quote(uint256 amount) external pure returns (uint256) {
+        return Pricing.price(amount) + Fees.fee(amount);
+    }
+}
+`
+
+func TestLinkReferences(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Shop.sol": {Content: externalLibraryContract},
+	}, "evm.bytecode.object", "evm.bytecode.linkReferences", "evm.deployedBytecode.object", "evm.deployedBytecode.linkReferences")
+	require.Empty(t, output.Errors)
+
+	shop := output.Contracts["Shop.sol"]["Shop"]
+	assert.Equal(t, []string{"Shop.sol:Fees", "Shop.sol:Pricing"}, shop.UnlinkedLibraries())
+
+	references := shop.EVM.DeployedBytecode.LinkReferences["Shop.sol"]["Pricing"]
+	require.NotEmpty(t, references)
+	for _, ref := range references {
+		assert.Equal(t, 20, ref.Length, "Placeholders should span an address")
+		assert.Equal(t, ref.Start+ref.Length, ref.End)
+		placeholder := shop.EVM.DeployedBytecode.Object[2*ref.Start : 2*(ref.Start+ref.Length)]
+		assert.True(t, strings.HasPrefix(placeholder, "__$") && strings.HasSuffix(placeholder, "$__"), placeholder)
+	}
+
+	// Libraries themselves need no linking
+	pricing := output.Contracts["Shop.sol"]["Pricing"]
+	assert.Empty(t, pricing.UnlinkedLibraries())
+	assert.True(t, pricing.IsLinked())
+	assert.False(t, shop.IsLinked())
+
+	// Placeholders alone, without link references, also count as unlinked
+	placeholdersOnly := Contract{}
+	placeholdersOnly.EVM.Bytecode.Object = shop.EVM.Bytecode.Object
+	assert.False(t, placeholdersOnly.IsLinked())
+
+	// Replacing the placeholders and clearing the references links the contract
+	linked := shop
+	for _, bytecode := range []*Bytecode{&linked.EVM.Bytecode, &linked.EVM.DeployedBytecode.Bytecode} {
+		for _, libraries := range bytecode.LinkReferences {
+			for _, refs := range libraries {
+				for _, ref := range refs {
+					bytecode.Object = bytecode.Object[:2*ref.Start] + strings.Repeat("11", ref.Length) + bytecode.Object[2*(ref.Start+ref.Length):]
+				}
+			}
+		}
+		bytecode.LinkReferences = nil
+	}
+	assert.True(t, linked.IsLinked())
+}
+
+func TestRangeContracts(t *testing.T) {
+	outputJSON := `{
+		"errors": [{"severity": "warning", "message": "unused variable"}],
+		"sources": {"A.sol": {"id": 0, "ast": {"nodes": [{"nodes": []}]}}, "B.sol": {"id": 1}},
+		"contracts": {
+			"B.sol": {"Second": {"evm": {"bytecode": {"object": "6002"}}}},
+			"A.sol": {"Zeta": {"evm": {"bytecode": {"object": "6001"}}}, "Alpha": {"evm": {"bytecode": {"object": "6000"}}}}
+		}
+	}`
+
+	// Streaming yields the contracts in document order
+	var streamed []string
+	err := RangeContracts(strings.NewReader(outputJSON), func(file, name string, c *Contract) bool {
+		streamed = append(streamed, file+":"+name+"="+c.EVM.Bytecode.Object)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"B.sol:Second=6002", "A.sol:Zeta=6001", "A.sol:Alpha=6000"}, streamed)
+
+	// Returning false stops the iteration
+	count := 0
+	err = RangeContracts(strings.NewReader(outputJSON), func(file, name string, c *Contract) bool {
+		count++
+		return false
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Range on a parsed output is sorted by file and name
+	output, err := ParseOutput(outputJSON)
+	require.NoError(t, err)
+	var ranged []string
+	output.Range(func(file, name string, c *Contract) bool {
+		ranged = append(ranged, file+":"+name)
+		return name != "Zeta"
+	})
+	assert.Equal(t, []string{"A.sol:Alpha", "A.sol:Zeta"}, ranged)
+
+	assert.NoError(t, RangeContracts(strings.NewReader(`{"errors": []}`), func(string, string, *Contract) bool {
+		t.Fatal("no contracts expected")
+		return true
+	}))
+	assert.ErrorContains(t, RangeContracts(strings.NewReader(`{"contracts": {"A.sol": {"A": 1}}}`), func(string, string, *Contract) bool {
+		return true
+	}), "failed to decode contract A.sol:A")
+	assert.ErrorContains(t, RangeContracts(strings.NewReader(`[]`), func(string, string, *Contract) bool {
+		return true
+	}), "failed to decode output")
+}
@@ -0,0 +1,365 @@
+package solc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pragmaPattern matches a Solidity version pragma and captures its constraint
+var pragmaPattern = regexp.MustCompile(`pragma\s+solidity\s+([^;]+);`)
+
+// ParsePragma extracts the version constraint from the `pragma solidity`
+// statements of a source file. Multiple pragmas must all be satisfied and are
+// joined into a single constraint. The result is normalized, with operators
+// attached to their versions and single spaces between comparators, e.g.
+// "pragma solidity >= 0.7.0  <0.9.0;" yields ">=0.7.0 <0.9.0". Pragmas in
+// comments are ignored. ErrNoPragma is returned if there is no pragma.
+func ParsePragma(source string) (string, error) {
+	matches := pragmaPattern.FindAllStringSubmatch(stripComments(source), -1)
+	if len(matches) == 0 {
+		return "", ErrNoPragma
+	}
+
+	// Every pragma must hold, so distribute the conjunction over alternatives:
+	// "^0.8.0" and "0.8.20 || 0.8.21" become "^0.8.0 0.8.20 || ^0.8.0 0.8.21"
+	alternatives := []string{""}
+	for _, match := range matches {
+		c := strings.Join(strings.Fields(operatorSpacePattern.ReplaceAllString(match[1], "$1")), " ")
+		if _, err := parseConstraint(c); err != nil {
+			return "", fmt.Errorf("invalid pragma %q: %w", strings.TrimSpace(match[0]), err)
+		}
+
+		var combined []string
+		for _, prefix := range alternatives {
+			for _, alternative := range strings.Split(c, "||") {
+				combined = append(combined, strings.TrimSpace(prefix+" "+strings.TrimSpace(alternative)))
+			}
+		}
+		alternatives = combined
+	}
+
+	return strings.Join(alternatives, " || "), nil
+}
+
+// stripComments removes line and block comments from Solidity source,
+// leaving string literals intact.
+func stripComments(source string) string {
+	var b strings.Builder
+	b.Grow(len(source))
+
+	for i := 0; i < len(source); i++ {
+		switch {
+		case source[i] == '"' || source[i] == '\'':
+			// Copy string literals verbatim, honoring escapes
+			quote := source[i]
+			b.WriteByte(quote)
+			for i++; i < len(source) && source[i] != quote && source[i] != '\n'; i++ {
+				if source[i] == '\\' && i+1 < len(source) {
+					b.WriteByte(source[i])
+					i++
+				}
+				b.WriteByte(source[i])
+			}
+			if i < len(source) {
+				b.WriteByte(source[i])
+			}
+		case strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end - 1
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			// Keep a separator so tokens around the comment stay apart
+			b.WriteByte(' ')
+			i += end + 3
+		default:
+			b.WriteByte(source[i])
+		}
+	}
+
+	return b.String()
+}
+
+// comparator is a single version comparison such as ">=0.8.0"
+type comparator struct {
+	op      string
+	version semver
+}
+
+// matches reports whether v satisfies the comparison.
+func (c comparator) matches(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// constraint is a disjunction of comparator sets, each of which must fully match
+type constraint [][]comparator
+
+// matches reports whether v satisfies the constraint.
+func (c constraint) matches(v semver) bool {
+	for _, set := range c {
+		ok := true
+		for _, comp := range set {
+			if !comp.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// comparatorPattern splits a comparator into its operator and (partial) version
+var comparatorPattern = regexp.MustCompile(`^(\^|~|>=|<=|>|<|=)?\s*v?([0-9xX*]+(?:\.[0-9xX*]+){0,2})$`)
+
+// operatorSpacePattern matches an operator followed by whitespace
+var operatorSpacePattern = regexp.MustCompile(`(\^|~|>=|<=|>|<|=)\s+`)
+
+// parseConstraint parses an npm-style semver range as used by Solidity
+// pragmas, e.g. "^0.8.0", ">=0.7.0 <0.9.0" or "0.6.12 || ^0.8.0".
+func parseConstraint(s string) (constraint, error) {
+	var c constraint
+	for _, alternative := range strings.Split(s, "||") {
+		set, err := parseComparatorSet(strings.TrimSpace(alternative))
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, set)
+	}
+	return c, nil
+}
+
+// parseComparatorSet parses space-separated comparators and hyphen ranges.
+func parseComparatorSet(s string) ([]comparator, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	// Join operators separated from their version by whitespace, e.g. ">= 0.8.0"
+	s = operatorSpacePattern.ReplaceAllString(s, "$1")
+	fields := strings.Fields(s)
+
+	var set []comparator
+	for i := 0; i < len(fields); i++ {
+		// Hyphen range: "0.7.0 - 0.8.0"
+		if i+2 < len(fields) && fields[i+1] == "-" {
+			low, _, err := parsePartial(fields[i])
+			if err != nil {
+				return nil, err
+			}
+			high, parts, err := parsePartial(fields[i+2])
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparator{">=", low})
+			if parts == 3 {
+				set = append(set, comparator{"<=", high})
+			} else {
+				set = append(set, comparator{"<", bumpPartial(high, parts)})
+			}
+			i += 2
+			continue
+		}
+
+		comps, err := parseComparator(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comps...)
+	}
+	return set, nil
+}
+
+// parseComparator expands a single comparator into primitive comparisons.
+func parseComparator(s string) ([]comparator, error) {
+	match := comparatorPattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, fmt.Errorf("invalid version constraint %q", s)
+	}
+	op := match[1]
+	v, parts, err := parsePartial(match[2])
+	if err != nil {
+		return nil, err
+	}
+	if parts == 0 {
+		// Wildcards like "*" match any version
+		return nil, nil
+	}
+
+	switch op {
+	case "^":
+		var upper semver
+		switch {
+		case v.major > 0 || parts == 1:
+			upper = semver{v.major + 1, 0, 0}
+		case v.minor > 0 || parts == 2:
+			upper = semver{0, v.minor + 1, 0}
+		default:
+			upper = semver{0, 0, v.patch + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case "~":
+		if parts == 1 {
+			return []comparator{{">=", v}, {"<", semver{v.major + 1, 0, 0}}}, nil
+		}
+		return []comparator{{">=", v}, {"<", semver{v.major, v.minor + 1, 0}}}, nil
+	case "", "=":
+		if parts < 3 {
+			return []comparator{{">=", v}, {"<", bumpPartial(v, parts)}}, nil
+		}
+		return []comparator{{"=", v}}, nil
+	case ">":
+		if parts < 3 {
+			return []comparator{{">=", bumpPartial(v, parts)}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case "<=":
+		if parts < 3 {
+			return []comparator{{"<", bumpPartial(v, parts)}}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	default:
+		return []comparator{{op, v}}, nil
+	}
+}
+
+// parsePartial parses a possibly partial version such as "0.8", "0.8.x" or
+// "*", returning the version with missing parts set to zero and the number of
+// specified parts.
+func parsePartial(s string) (semver, int, error) {
+	var nums [3]int
+	parts := 0
+	for i, part := range strings.Split(s, ".") {
+		if i > 2 {
+			return semver{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return semver{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+		parts++
+	}
+	return semver{nums[0], nums[1], nums[2]}, parts, nil
+}
+
+// bumpPartial returns the lowest version above all versions matching a partial version.
+func bumpPartial(v semver, parts int) semver {
+	switch parts {
+	case 1:
+		return semver{v.major + 1, 0, 0}
+	case 2:
+		return semver{v.major, v.minor + 1, 0}
+	default:
+		return semver{v.major, v.minor, v.patch + 1}
+	}
+}
+
+// localVersions returns the registered and embedded versions, which can be
+// used without downloading.
+func localVersions() []string {
+	versions := GetEmbeddedVersions()
+	registeredMu.RLock()
+	for version := range registeredVersions {
+		versions = append(versions, version)
+	}
+	registeredMu.RUnlock()
+	return versions
+}
+
+// highestMatching returns the highest of the given versions satisfying all constraints.
+func highestMatching(versions []string, constraints []constraint) (string, bool) {
+	var candidates []semver
+	for _, version := range versions {
+		v, err := parseSemver(version)
+		if err != nil || strings.ContainsAny(version, "+-") {
+			continue
+		}
+		ok := true
+		for _, c := range constraints {
+			if !c.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].compare(candidates[j]) > 0 })
+	return candidates[0].String(), true
+}
+
+// resolveConstraints picks the best compiler version satisfying all constraints:
+// the highest registered or embedded version if any matches, otherwise the
+// highest release listed on the binaries server.
+func resolveConstraints(constraints []constraint) (string, error) {
+	if version, ok := highestMatching(localVersions(), constraints); ok {
+		return version, nil
+	}
+
+	versionList, err := getVersionList()
+	if err != nil {
+		return "", err
+	}
+	releases := make([]string, 0, len(versionList.Releases))
+	for version := range versionList.Releases {
+		releases = append(releases, version)
+	}
+	if version, ok := highestMatching(releases, constraints); ok {
+		return version, nil
+	}
+
+	return "", fmt.Errorf("%w: no release satisfies the constraints", ErrVersionNotFound)
+}
+
+// VersionForPragma resolves the best compiler version for a version pragma,
+// given either as a constraint ("^0.8.0") or a full statement
+// ("pragma solidity ^0.8.0;"). Embedded and registered versions are preferred
+// so that no download is needed; otherwise the highest matching release is
+// returned.
+func VersionForPragma(pragma string) (string, error) {
+	if match := pragmaPattern.FindStringSubmatch(pragma); match != nil {
+		pragma = match[1]
+	}
+
+	c, err := parseConstraint(strings.TrimSpace(pragma))
+	if err != nil {
+		return "", err
+	}
+
+	version, err := resolveConstraints([]constraint{c})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve pragma %q: %w", pragma, err)
+	}
+	return version, nil
+}
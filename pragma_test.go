@@ -0,0 +1,144 @@
+package solc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		match      bool
+	}{
+		{"^0.8.0", "0.8.0", true},
+		{"^0.8.0", "0.8.30", true},
+		{"^0.8.0", "0.9.0", false},
+		{"^0.8.0", "0.7.6", false},
+		{"^0.0.3", "0.0.4", false},
+		{"^1.2.3", "1.9.0", true},
+		{"~0.8.1", "0.8.9", true},
+		{"~0.8.1", "0.9.0", false},
+		{">=0.7.0 <0.9.0", "0.8.21", true},
+		{">=0.7.0 <0.9.0", "0.9.0", false},
+		{">= 0.7.0 < 0.9.0", "0.7.0", true},
+		{"0.8.21", "0.8.21", true},
+		{"=0.8.21", "0.8.22", false},
+		{"0.8", "0.8.99", true},
+		{"0.8.x", "0.9.0", false},
+		{">0.8", "0.8.5", false},
+		{">0.8", "0.9.0", true},
+		{"<=0.8", "0.8.5", true},
+		{"0.6.12 || ^0.8.0", "0.6.12", true},
+		{"0.6.12 || ^0.8.0", "0.7.0", false},
+		{"0.7.0 - 0.8.0", "0.8.0", true},
+		{"0.7.0 - 0.8", "0.8.5", true},
+		{"*", "0.4.26", true},
+	}
+
+	for _, tt := range tests {
+		c, err := parseConstraint(tt.constraint)
+		require.NoError(t, err, tt.constraint)
+		v, err := parseSemver(tt.version)
+		require.NoError(t, err)
+		assert.Equal(t, tt.match, c.matches(v), "%s ~ %s", tt.constraint, tt.version)
+	}
+
+	for _, invalid := range []string{"", "^abc", ">=0.8.0 <", "0.8.0.1"} {
+		_, err := parseConstraint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestVersionForPragma(t *testing.T) {
+	tests := []struct {
+		pragma  string
+		version string
+	}{
+		{"^0.8.0", "0.8.30"},
+		{">=0.8.0 <0.8.25", "0.8.21"},
+		{"pragma solidity =0.8.21;", "0.8.21"},
+	}
+
+	for _, tt := range tests {
+		version, err := VersionForPragma(tt.pragma)
+		require.NoError(t, err, tt.pragma)
+		assert.Equal(t, tt.version, version, tt.pragma)
+	}
+
+	_, err := VersionForPragma("not a pragma")
+	assert.Error(t, err)
+}
+
+func TestMultiCompiler(t *testing.T) {
+	compiler := NewMultiCompiler()
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Old.sol":      {Content: "pragma solidity =0.8.21; import \"./lib/Math.sol\"; contract Old { function f() public pure returns (uint256) { return Math.add(1, 2); } }"},
+			"New.sol":      {Content: "pragma solidity ^0.8.25; contract New {}"},
+			"lib/Math.sol": {Content: mathLibrary},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	}
+
+	outputs, err := compiler.Compile(input, nil)
+	require.NoError(t, err)
+	require.Contains(t, outputs, "0.8.21")
+	require.Contains(t, outputs, "0.8.30")
+
+	// Old.sol pins the library to 0.8.21 while New.sol alone goes to 0.8.30
+	assert.False(t, outputs["0.8.21"].HasErrors())
+	assert.Contains(t, outputs["0.8.21"].Contracts, "Old.sol")
+	assert.NotContains(t, outputs["0.8.21"].Contracts, "New.sol")
+	assert.Contains(t, outputs["0.8.30"].Contracts, "New.sol")
+	assert.Contains(t, outputs["0.8.30"].Contracts, "lib/Math.sol")
+}
+
+func TestParsePragma(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		constraint string
+	}{
+		{"caret", "pragma solidity ^0.8.0;\ncontract A {}", "^0.8.0"},
+		{"range", "pragma solidity >=0.7.0 <0.9.0;", ">=0.7.0 <0.9.0"},
+		{"spacing", "pragma   solidity >= 0.7.0\t  < 0.9.0 ;", ">=0.7.0 <0.9.0"},
+		{"multiple", "pragma solidity >=0.7.0;\npragma abicoder v2;\npragma solidity <0.9.0;", ">=0.7.0 <0.9.0"},
+		{"alternatives", "pragma solidity ^0.8.0;\npragma solidity 0.8.20 || 0.8.21;", "^0.8.0 0.8.20 || ^0.8.0 0.8.21"},
+		{"comments", "// pragma solidity ^0.4.0;\n/* pragma solidity ^0.5.0; */\npragma solidity ^0.8.0;", "^0.8.0"},
+		{"string", "pragma solidity ^0.8.0; contract A { string s = \"// not a comment\"; }", "^0.8.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint, err := ParsePragma(tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.constraint, constraint)
+
+			// The normalized constraint is usable by the version resolver
+			_, err = parseConstraint(constraint)
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err := ParsePragma("contract NoPragma {}")
+	assert.ErrorIs(t, err, ErrNoPragma)
+
+	_, err = ParsePragma("pragma solidity ^banana;")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoPragma)
+}
+
+func TestStripComments(t *testing.T) {
+	source := "a // line\nb /* block\n */ c \"// kept\" 'also /* kept */' \"esc\\\"//\""
+	assert.Equal(t, "a \nb   c \"// kept\" 'also /* kept */' \"esc\\\"//\"", stripComments(source))
+}
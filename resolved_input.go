@@ -0,0 +1,129 @@
+package solc
+
+import (
+	"fmt"
+)
+
+// ResolvedInput holds an input together with all of its resolved imports, so
+// a project can be recompiled after editing some files without resolving the
+// whole import tree again. Only the imports of changed files go through the
+// callback; imported files that are no longer reachable are dropped.
+//
+// A ResolvedInput is not safe for concurrent use.
+type ResolvedInput struct {
+	input    *Input
+	callback ImportCallback
+	// roots are the sources supplied by the caller rather than imported
+	roots map[string]bool
+	// aliases persists import paths remapped to a callback-reported path
+	aliases map[string]string
+}
+
+// ResolveInput resolves all imports of the input through the callback and
+// returns a handle for incremental recompilation. The input is left unchanged.
+func ResolveInput(input *Input, callback ImportCallback) (*ResolvedInput, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input cannot be nil")
+	}
+	if callback == nil {
+		return nil, fmt.Errorf("import callback cannot be nil")
+	}
+
+	r := &ResolvedInput{
+		input:    input.Clone(),
+		callback: callback,
+		roots:    make(map[string]bool, len(input.Sources)),
+		aliases:  make(map[string]string),
+	}
+	if r.input.Sources == nil {
+		r.input.Sources = make(map[string]SourceIn)
+	}
+	for name := range input.Sources {
+		r.roots[name] = true
+	}
+
+	if err := r.resolve(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Input returns the input with all imports resolved. It must not be modified
+// directly; use SetSource and RemoveSource instead.
+func (r *ResolvedInput) Input() *Input {
+	return r.input
+}
+
+// SetSource adds or replaces a source and resolves the imports it newly
+// references. Previously resolved files are reused without calling the
+// callback again.
+func (r *ResolvedInput) SetSource(name, content string) error {
+	r.roots[name] = true
+	r.input.Sources[name] = SourceIn{Content: content}
+	return r.resolve()
+}
+
+// RemoveSource removes a source along with the imported files only it used.
+func (r *ResolvedInput) RemoveSource(name string) error {
+	delete(r.roots, name)
+	delete(r.input.Sources, name)
+	return r.resolve()
+}
+
+// Compile compiles the resolved input. Any ImportCallback in options is not
+// needed and ignored, since all imports have already been resolved.
+func (r *ResolvedInput) Compile(compiler Solc, options *CompileOptions) (*Output, error) {
+	if compiler == nil {
+		return nil, fmt.Errorf("compiler cannot be nil")
+	}
+
+	var compileOptions CompileOptions
+	if options != nil {
+		compileOptions = *options
+	}
+	compileOptions.ImportCallback = nil
+	return compiler.CompileWithOptions(r.input, &compileOptions)
+}
+
+// resolve fetches missing imports of all sources and drops imported files
+// that are no longer reachable from the roots.
+func (r *ResolvedInput) resolve() error {
+	resolver := newImportResolver(r.callback)
+	resolver.aliases = r.aliases
+	if _, err := resolver.resolveImports(r.input); err != nil {
+		return fmt.Errorf("%w: %w", ErrImportFailed, err)
+	}
+
+	reachable := make(map[string]bool, len(r.input.Sources))
+	var pending []string
+	for name := range r.roots {
+		reachable[name] = true
+		pending = append(pending, name)
+	}
+	for len(pending) > 0 {
+		file := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		imports, err := resolver.extractImports(r.input.Sources[file].Content)
+		if err != nil {
+			return fmt.Errorf("failed to extract imports from %s: %w", file, err)
+		}
+		for _, importPath := range imports {
+			resolved := resolver.resolveAbsolutePath(importPath, file)
+			if alias, exists := r.aliases[resolved]; exists {
+				resolved = alias
+			}
+			if _, exists := r.input.Sources[resolved]; exists && !reachable[resolved] {
+				reachable[resolved] = true
+				pending = append(pending, resolved)
+			}
+		}
+	}
+
+	for name := range r.input.Sources {
+		if !reachable[name] {
+			delete(r.input.Sources, name)
+		}
+	}
+	return nil
+}
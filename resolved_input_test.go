@@ -0,0 +1,72 @@
+package solc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvedInput(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	files := map[string]string{
+		"lib/Math.sol":   mathLibrary,
+		"lib/String.sol": stringLibrary,
+	}
+	var requested []string
+	callback := func(url string) ImportResult {
+		requested = append(requested, url)
+		content, exists := files[url]
+		if !exists {
+			return ImportResult{Error: "not found"}
+		}
+		return ImportResult{Contents: content}
+	}
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: contractWithImport},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi"}},
+			},
+		},
+	}
+
+	resolved, err := ResolveInput(input, callback)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lib/Math.sol"}, requested)
+	assert.Len(t, input.Sources, 1, "Input should be left unchanged")
+
+	output, err := resolved.Compile(compiler, nil)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+
+	// Editing a file only resolves its new imports
+	requested = nil
+	require.NoError(t, resolved.SetSource("Calculator.sol", contractWithMultipleImports))
+	assert.Equal(t, []string{"lib/String.sol"}, requested, "Math.sol should be reused")
+
+	output, err = resolved.Compile(compiler, nil)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+	assert.Contains(t, output.Contracts, "lib/String.sol")
+
+	// Imports that are no longer used are dropped
+	requested = nil
+	require.NoError(t, resolved.SetSource("Calculator.sol", contractWithImport))
+	assert.Empty(t, requested)
+	assert.ElementsMatch(t, []string{"Calculator.sol", "lib/Math.sol"}, keys(resolved.Input().Sources))
+
+	require.NoError(t, resolved.RemoveSource("Calculator.sol"))
+	assert.Empty(t, resolved.Input().Sources)
+
+	// Failing imports are reported
+	err = resolved.SetSource("Broken.sol", "pragma solidity ^0.8.0;\nimport \"./Missing.sol\";")
+	assert.ErrorContains(t, err, "Missing.sol")
+}
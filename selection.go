@@ -0,0 +1,192 @@
+package solc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultOutputSelection returns the output selection used by
+// CompileWithOptions when the input selects nothing: the ABI, the creation
+// and runtime bytecode and the metadata of every contract.
+func DefaultOutputSelection() map[string]map[string][]string {
+	return map[string]map[string][]string{
+		"*": {"*": []string{"abi", "evm.bytecode", "evm.deployedBytecode", "metadata"}},
+	}
+}
+
+// isSelectionPattern reports whether an output selection key is a glob
+// pattern that solc cannot match itself. The wildcard "*" and the empty
+// file-level key are understood natively.
+func isSelectionPattern(key string) bool {
+	return key != "*" && strings.ContainsAny(key, "*?[")
+}
+
+// hasSelectionPatterns reports whether any file or contract key of the
+// selection is a glob pattern.
+func hasSelectionPatterns(selection map[string]map[string][]string) bool {
+	for file, contracts := range selection {
+		if isSelectionPattern(file) {
+			return true
+		}
+		for contract := range contracts {
+			if isSelectionPattern(contract) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandOutputSelection returns a copy of the input whose output selection
+// has glob patterns replaced by the matching source and contract names. The
+// contract names are found by a parse-only compilation of the sources. If
+// that pass reports errors, the input is returned unchanged so the actual
+// compilation reports them.
+func (s *baseSolc) expandOutputSelection(input *Input) (*Input, error) {
+	parseInput := *input
+	parseInput.Settings = Settings{
+		Remappings: input.Settings.Remappings,
+		EVMVersion: input.Settings.EVMVersion,
+		OutputSelection: map[string]map[string][]string{
+			"*": {"": []string{"ast"}},
+		},
+	}
+	if versionSupports(s.shortVersion, FeatureStopAfter) {
+		parseInput.Settings.StopAfter = "parsing"
+	}
+
+	inputJSON, err := json.Marshal(&parseInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+	outputJSON, err := s.compileJSON(inputJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed Output
+	if err := json.Unmarshal([]byte(outputJSON), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
+	}
+	if parsed.HasErrors() {
+		return input, nil
+	}
+
+	contracts := make(map[string][]string, len(parsed.Sources))
+	for file, source := range parsed.Sources {
+		names, err := contractNames(source.AST)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read contracts of %s: %w", file, err)
+		}
+		contracts[file] = names
+	}
+
+	expanded := *input
+	expanded.Settings.OutputSelection = expandSelection(input.Settings.OutputSelection, contracts)
+	return &expanded, nil
+}
+
+// expandSelection replaces glob patterns in the selection with the matching
+// names from contracts, which maps source names to their contract names.
+func expandSelection(selection map[string]map[string][]string, contracts map[string][]string) map[string]map[string][]string {
+	files := make([]string, 0, len(contracts))
+	for file := range contracts {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	expanded := make(map[string]map[string][]string)
+	add := func(file, contract string, outputs []string) {
+		if expanded[file] == nil {
+			expanded[file] = make(map[string][]string)
+		}
+		for _, output := range outputs {
+			if !containsString(expanded[file][contract], output) {
+				expanded[file][contract] = append(expanded[file][contract], output)
+			}
+		}
+	}
+
+	for fileKey, contractSelection := range selection {
+		matchedFiles := []string{fileKey}
+		if isSelectionPattern(fileKey) {
+			matchedFiles = nil
+			for _, file := range files {
+				if matchAnyGlob([]string{fileKey}, file) {
+					matchedFiles = append(matchedFiles, file)
+				}
+			}
+		}
+
+		for contractKey, outputs := range contractSelection {
+			if !isSelectionPattern(contractKey) {
+				for _, file := range matchedFiles {
+					add(file, contractKey, outputs)
+				}
+				continue
+			}
+
+			for _, file := range matchedFiles {
+				// A "*" file key applies to the contracts of every source
+				candidates := files
+				if file != "*" {
+					candidates = []string{file}
+				}
+				for _, candidate := range candidates {
+					for _, name := range contracts[candidate] {
+						if matchAnyGlob([]string{contractKey}, name) {
+							add(candidate, name, outputs)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Sort the merged outputs so the expanded input is deterministic
+	for _, contractSelection := range expanded {
+		for _, outputs := range contractSelection {
+			sort.Strings(outputs)
+		}
+	}
+
+	return expanded
+}
+
+// contractNames returns the names of the contracts, interfaces and libraries
+// defined at the top level of a source unit AST.
+func contractNames(ast json.RawMessage) ([]string, error) {
+	if len(ast) == 0 {
+		return nil, nil
+	}
+
+	var unit struct {
+		Nodes []struct {
+			NodeType string `json:"nodeType"`
+			Name     string `json:"name"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(ast, &unit); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, node := range unit.Nodes {
+		if node.NodeType == "ContractDefinition" {
+			names = append(names, node.Name)
+		}
+	}
+	return names, nil
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
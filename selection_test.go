@@ -0,0 +1,131 @@
+package solc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatedContracts = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+contract GeneratedA { function a() public pure returns (uint256) { return 1; } }
+contract GeneratedB { function b() public pure returns (uint256) { return 2; } }
+contract Handwritten { function c() public pure returns (uint256) { return 3; } }
+`
+
+func TestExpandSelection(t *testing.T) {
+	contracts := map[string][]string{
+		"src/Gen.sol":   {"GeneratedA", "GeneratedB", "Handwritten"},
+		"src/Other.sol": {"GeneratedC"},
+		"lib/Lib.sol":   {"Lib"},
+	}
+
+	expanded := expandSelection(map[string]map[string][]string{
+		"src/Gen.sol": {"Generated*": {"abi"}, "": {"ast"}},
+		"*":           {"Generated?": {"evm.bytecode.object"}, "Lib": {"abi"}},
+		"lib/*.sol":   {"*": {"metadata"}},
+	}, contracts)
+
+	assert.Equal(t, map[string]map[string][]string{
+		"src/Gen.sol": {
+			"GeneratedA": {"abi", "evm.bytecode.object"},
+			"GeneratedB": {"abi", "evm.bytecode.object"},
+			"":           {"ast"},
+		},
+		"src/Other.sol": {"GeneratedC": {"evm.bytecode.object"}},
+		"*":             {"Lib": {"abi"}},
+		"lib/Lib.sol":   {"*": {"metadata"}},
+	}, expanded)
+
+	assert.False(t, hasSelectionPatterns(map[string]map[string][]string{"*": {"*": {"abi"}, "": {"ast"}}}))
+}
+
+func TestExpandSelectionPatterns(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Gen.sol": {Content: generatedContracts},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"Generated*": []string{"evm.bytecode.object"}},
+			},
+		},
+	}
+
+	output, err := compiler.CompileWithOptions(input, &CompileOptions{ExpandSelectionPatterns: true})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+
+	contracts := output.Contracts["Gen.sol"]
+	assert.NotEmpty(t, contracts["GeneratedA"].EVM.Bytecode.Object)
+	assert.NotEmpty(t, contracts["GeneratedB"].EVM.Bytecode.Object)
+	assert.Empty(t, contracts["Handwritten"].EVM.Bytecode.Object, "Non-matching contract should not be selected")
+	assert.Contains(t, input.Settings.OutputSelection["*"], "Generated*", "Input should be left unchanged")
+
+	// Without expansion, solc treats the pattern as a literal name
+	output, err = compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	assert.Empty(t, output.Contracts["Gen.sol"]["GeneratedA"].EVM.Bytecode.Object)
+}
+
+func TestDefaultOutputSelection(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+		},
+	}
+
+	output, err := compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), output.Errors)
+	greeter := output.Contracts["Greeter.sol"]["Greeter"]
+	assert.NotEmpty(t, greeter.ABI)
+	assert.NotEmpty(t, greeter.Metadata)
+	assert.NotEmpty(t, greeter.EVM.Bytecode.Object)
+	assert.NotEmpty(t, greeter.EVM.DeployedBytecode.Object)
+	assert.Nil(t, input.Settings.OutputSelection, "Input should be left unchanged")
+
+	// Opting out sends the empty selection to solc
+	output, err = compiler.CompileWithOptions(input, &CompileOptions{NoDefaultOutputSelection: true})
+	require.NoError(t, err)
+	assert.True(t, output.IsEmpty())
+}
+
+func TestStopAfterParsingWithoutSelection(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+			"Broken.sol":  {Content: "pragma solidity ^0.8.0;\ncontract Broken {"},
+		},
+		Settings: Settings{StopAfter: "parsing"},
+	}
+
+	output, err := compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	require.True(t, output.HasErrors())
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			assert.Equal(t, "ParserError", e.Type, e.Message)
+		}
+	}
+	assert.Empty(t, output.Contracts)
+	assert.Nil(t, input.Settings.OutputSelection, "Input should be left unchanged")
+}
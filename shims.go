@@ -0,0 +1,53 @@
+package solc
+
+import (
+	_ "embed"
+)
+
+//go:embed embedded-shims/hardhat/console.sol
+var hardhatConsoleSol string
+
+// consoleShims maps well-known import paths to the embedded sources served
+// with CompileOptions.IncludeConsoleLog
+var consoleShims = map[string]string{
+	"hardhat/console.sol": hardhatConsoleSol,
+}
+
+// importCallback returns the import callback to use for a compilation:
+// the user's callback, or a DirImportCallback confined to the base path and
+// include paths if there is none but IncludePaths are set. With
+// IncludeConsoleLog, imports of well-known console shims that the callback
+// does not resolve (or all of them, if there is no callback) are served from
+// the embedded sources. It returns nil if there is no callback.
+func (o *CompileOptions) importCallback() ImportCallback {
+	if o == nil {
+		return nil
+	}
+	callback := o.ImportCallback
+	if callback == nil && len(o.IncludePaths) > 0 {
+		basePath := o.BasePath
+		if basePath == "" {
+			basePath = "."
+		}
+		callback = DirImportCallback(append([]string{basePath}, o.IncludePaths...)...)
+	}
+	if !o.IncludeConsoleLog {
+		return callback
+	}
+
+	return func(url string) ImportResult {
+		var result ImportResult
+		if callback != nil {
+			if result = callback(url); result.Error == "" {
+				return result
+			}
+		}
+		if content, ok := consoleShims[url]; ok {
+			return ImportResult{Contents: content}
+		}
+		if callback == nil {
+			return ImportResult{Error: "File not found"}
+		}
+		return result
+	}
+}
@@ -0,0 +1,79 @@
+package solc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const consoleLogContract = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+import "hardhat/console.sol";
+
+contract Debugged {
+    function run(uint256 amount) external view {
+        console.log("amount", amount);
+        console.log(msg.sender, true);
+        console.logBytes32(bytes32(amount));
+    }
+}
+`
+
+func TestIncludeConsoleLog(t *testing.T) {
+	input := &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Debugged.sol": {Content: consoleLogContract}},
+		Settings: Settings{OutputSelection: map[string]map[string][]string{"*": {"*": []string{"evm.bytecode.object"}}}},
+	}
+
+	for _, version := range []string{"0.8.21", "0.8.30"} {
+		compiler, err := NewWithVersion(version)
+		require.NoError(t, err)
+		defer compiler.Close()
+
+		// Without the option the import is missing
+		_, err = compiler.CompileWithOptions(input.Clone(), nil)
+		require.ErrorIs(t, err, ErrImportFailed, version)
+
+		// The shim is served without any callback
+		output, err := compiler.CompileWithOptions(input.Clone(), &CompileOptions{IncludeConsoleLog: true})
+		require.NoError(t, err, version)
+		require.Empty(t, output.Errors, version)
+		bytecode := output.Contracts["Debugged.sol"]["Debugged"].EVM.Bytecode.Object
+		assert.Contains(t, strings.ToLower(bytecode), "636f6e736f6c652e6c6f67", "Bytecode should call the console address")
+	}
+
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	// The user's callback takes precedence, and the shim fills in its misses
+	var requested []string
+	options := &CompileOptions{
+		IncludeConsoleLog: true,
+		ImportCallback: func(url string) ImportResult {
+			requested = append(requested, url)
+			if url == "lib/Math.sol" {
+				return ImportResult{Contents: mathLibrary}
+			}
+			return ImportResult{Error: "not found"}
+		},
+	}
+	withMath := input.Clone()
+	withMath.Sources["Debugged.sol"] = SourceIn{Content: consoleLogContract + `import "lib/Math.sol";`}
+	output, err := compiler.CompileWithOptions(withMath, options)
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	assert.ElementsMatch(t, []string{"hardhat/console.sol", "lib/Math.sol"}, requested)
+
+	// Other misses are still reported
+	_, err = compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"A.sol": {Content: `pragma solidity ^0.8.0; import "lib/Missing.sol";`}},
+	}, options)
+	assert.ErrorIs(t, err, ErrImportFailed)
+}
@@ -2,10 +2,12 @@ package solc
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"rogchap.com/v8go"
 )
@@ -16,6 +18,11 @@ type ImportResult struct {
 	Contents string `json:"contents,omitempty"`
 	// Error holds the error message if import failed.
 	Error string `json:"error,omitempty"`
+	// ResolvedPath optionally reports the canonical path the import resolved
+	// to, e.g. "lib/Math.sol" for "./Math.sol". When set, the contents are
+	// added under this source key and the requested path is remapped to it, so
+	// files reached through different paths are only compiled once.
+	ResolvedPath string `json:"resolvedPath,omitempty"`
 }
 
 // ImportCallback is a function that resolves import statements.
@@ -26,17 +33,158 @@ type ImportCallback func(url string) ImportResult
 type CompileOptions struct {
 	// ImportCallback handles import resolution.
 	ImportCallback ImportCallback
+	// Stats, when non-nil, is populated with statistics about the compilation.
+	Stats *CompileStats
+	// BasePath is the directory imports are loaded from, like solc's
+	// --base-path, e.g. "contracts" to compile an in-memory "Main.sol" whose
+	// "./lib/Math.sol" lives at "contracts/lib/Math.sol". It is joined to the
+	// source unit name of every import before calling the ImportCallback; the
+	// sources are still keyed by the names solc resolves, so outputs and
+	// remappings, which solc applies to source unit names, do not see it. Use
+	// IncludePaths to load direct imports such as "@openzeppelin/..." from
+	// elsewhere.
+	BasePath string
+	// IncludePaths lists directories searched, in order, for imports the
+	// callback cannot load from the base path, like solc's --include-path,
+	// e.g. "node_modules" to find "@openzeppelin/..." packages. Each import
+	// is requested joined to the include path only if the previous location
+	// failed; the source keeps its source unit name. Without an
+	// ImportCallback, they are read from disk with a DirImportCallback that
+	// only allows files under the base path (or the working directory) and
+	// the include paths.
+	IncludePaths []string
+	// MaxImportDepth limits the length of import chains resolved through
+	// the ImportCallback. Zero uses the default of 50.
+	MaxImportDepth int
+	// ImportTimeout bounds each ImportCallback invocation. An import that
+	// takes longer fails with an ImportResult error naming the timeout; the
+	// callback itself keeps running in the background. Zero waits forever.
+	ImportTimeout time.Duration
+	// ImportConcurrency is the maximum number of ImportCallback invocations
+	// in flight, used to fetch the imports of a file in parallel. Values
+	// above one require a callback that is safe for concurrent use. Zero
+	// or one fetches imports one at a time.
+	ImportConcurrency int
+	// ImportFailFast stops import resolution at the first import the
+	// ImportCallback fails to resolve. By default resolution goes on and all
+	// failures are returned at once, joined with errors.Join, so every
+	// missing dependency is reported.
+	ImportFailFast bool
+	// AllowMissingImports skips the check for imports that are neither supplied
+	// in the sources nor resolvable without an ImportCallback, leaving them for
+	// solc to report.
+	AllowMissingImports bool
+	// NoDefaultOutputSelection sends an empty output selection to solc as
+	// is, which produces no artifacts, instead of using DefaultOutputSelection.
+	NoDefaultOutputSelection bool
+	// ExpandSelectionPatterns expands glob patterns such as "Generated*" or
+	// "contracts/**/*.sol" in the file and contract keys of the output
+	// selection to the matching names, which solc itself does not support
+	// beyond "*". Finding the contract names takes an extra parse-only pass.
+	ExpandSelectionPatterns bool
+	// StripBOM removes a leading UTF-8 byte order mark from every source.
+	StripBOM bool
+	// NormalizeLineEndings converts CRLF and CR line endings to LF in every source.
+	// Note that this changes the source hashes recorded in the metadata.
+	NormalizeLineEndings bool
+	// DefaultSPDX, when set, prepends "// SPDX-License-Identifier: <DefaultSPDX>"
+	// to every Solidity source without an SPDX license identifier, e.g. "MIT"
+	// for generated contracts. Sources that have one are left untouched. Like
+	// the other normalizations this changes the source hashes, and diagnostics
+	// of the changed sources are shifted down by one line.
+	DefaultSPDX string
+	// StrictWarnings treats warnings as errors, e.g. for CI gates: if the
+	// compiler reports any error or warning, the output is returned together
+	// with a *DiagnosticsError carrying them. Errors take precedence, so the
+	// error wraps ErrCompilationFailed if there are any and ErrWarnings otherwise.
+	StrictWarnings bool
+	// IncludeConsoleLog serves "hardhat/console.sol" from an embedded shim
+	// compatible with solc >= 0.8.0 when the ImportCallback does not resolve
+	// it, or when there is no ImportCallback, so console.log debugging works
+	// without installing Hardhat.
+	IncludeConsoleLog bool
+	// FailFast returns a *DiagnosticsError wrapping ErrCompilationFailed with
+	// only the first error reported by the compiler, e.g. to show a single
+	// problem in a CLI. solc still analyzes all sources and the output holds
+	// every diagnostic; this only changes what is surfaced as the Go error.
+	FailFast bool
+	// CaptureInput, when non-nil, receives the standard JSON input exactly as
+	// sent to the compiler, after import resolution, the default output
+	// selection and source normalization, e.g. to reproduce an issue with the
+	// solc CLI. It is left untouched if no compilation happens, such as on a
+	// CompileCached hit.
+	CaptureInput *string
+}
+
+// CompileStats holds timing and size statistics of a single compilation.
+type CompileStats struct {
+	// CompileDuration is the wall-clock time spent in the native compiler.
+	CompileDuration time.Duration
+	// ImportsResolved is the number of import callback invocations.
+	ImportsResolved int
+	// InputSize is the size in bytes of the standard JSON input sent to the compiler.
+	InputSize int
+	// OutputSize is the size in bytes of the standard JSON output returned by the compiler.
+	OutputSize int
+	// CacheHit reports whether CompileCached returned a cached output
+	// without invoking the compiler.
+	CacheHit bool
+}
+
+// CompileResult is the output of a compilation together with its provenance.
+type CompileResult struct {
+	Output *Output
+	// CompilerVersion is the commit-qualified compiler version, e.g.
+	// "0.8.21+commit.d9974bed".
+	CompilerVersion string
+	// EVMVersion is the EVM version compiled for: Settings.EVMVersion, or the
+	// compiler's default if it was not set.
+	EVMVersion string
 }
 
 // Solc represents a Solidity compiler interface.
 type Solc interface {
-	// License returns the license information of the compiler.
+	// License returns the license information of the compiler. It is read
+	// once at init, so it never waits for a running compilation.
 	License() string
-	// Version returns the version information of the compiler.
+	// Version returns the version information of the compiler. Like License
+	// it is cached at init.
 	Version() string
+	// ShortVersion returns the release version of the compiler, e.g. "0.8.21".
+	ShortVersion() string
+	// LongVersion returns the commit-qualified version of the compiler,
+	// e.g. "0.8.21+commit.d9974bed".
+	LongVersion() string
 	// CompileWithOptions compiles Solidity source code with additional options like import callbacks.
 	// Pass nil for options to use default compilation without import callbacks.
 	CompileWithOptions(input *Input, options *CompileOptions) (*Output, error)
+	// CompileWithResult compiles like CompileWithOptions and bundles the
+	// output with the compiler and EVM versions that produced it.
+	CompileWithResult(input *Input, options *CompileOptions) (*CompileResult, error)
+	// Supports reports whether the compiler version supports the given feature,
+	// e.g. FeatureStorageLayout. Unknown features are reported as unsupported.
+	Supports(feature string) bool
+	// DefaultEVMVersion returns the EVM version the compiler targets when
+	// Settings.EVMVersion is not set, e.g. "shanghai" for 0.8.21.
+	DefaultEVMVersion() string
+	// CallExport calls a raw export of the emscripten module with string
+	// arguments. This is an advanced and unsafe escape hatch: calling exports
+	// that mutate compiler state can break subsequent compilations.
+	CallExport(name string, returnType string, args ...string) (string, error)
+	// WithContext calls fn with the underlying V8 isolate and context, e.g. to
+	// inject helper globals. This is an advanced and unsafe escape hatch: fn
+	// runs while holding the compiler's lock, so it must not call methods of
+	// the compiler, and it must not keep the values around after returning.
+	WithContext(fn func(isolate *v8go.Isolate, ctx *v8go.Context) error) error
+	// Ping checks that the compiler instance is still usable, returning an
+	// error if it has been closed or its JavaScript context is broken.
+	Ping() error
+	// TrimMemory releases memory the compiler retains between compilations,
+	// e.g. between the inputs of a long-running loop on one instance.
+	TrimMemory() error
+	// HeapStats returns the memory usage of the compiler's V8 isolate, e.g. to
+	// recycle instances whose heap grows too large.
+	HeapStats() (v8go.HeapStatistics, error)
 	// Close releases all resources associated with the compiler instance.
 	Close() error
 }
@@ -46,59 +194,210 @@ type baseSolc struct {
 	isolate *v8go.Isolate
 	ctx     *v8go.Context
 
+	// ownsIsolate is false when the isolate was provided by the caller
+	ownsIsolate bool
+
 	// mu protects the underlying v8 context from concurrent access
 	mu sync.Mutex
 
+	// version is the bound version function, called by Ping
 	version *v8go.Function
-	license *v8go.Function
+
+	// versionString and license are constant for a binary and read once at
+	// init, so Version and License need no lock
+	versionString string
+	license       string
+
+	// shortVersion and longVersion are parsed from the version once at init
+	shortVersion string
+	longVersion  string
 
 	closed bool
+
+	// stopCloseOnDone unregisters the context callback of NewWithVersionContext
+	stopCloseOnDone func() bool
+}
+
+// InstanceOptions configures the creation of a compiler instance.
+type InstanceOptions struct {
+	// DisableJIT runs the compiler with V8's optimizing tiers turned off
+	// ("--no-opt" and "--no-wasm-tier-up"), trading speed for fully
+	// deterministic baseline execution. V8 flags are process-wide and
+	// cannot be reverted, so this also applies to every compiler instance
+	// created afterwards in the same process.
+	DisableJIT bool
+	// Isolate runs the compiler in a caller-owned isolate instead of a new
+	// one, e.g. to share it with other JavaScript. The compiler gets its own
+	// context and Close leaves the isolate alive. This is advanced and
+	// unsafe: isolates are single-threaded, and the compiler's lock only
+	// serializes its own calls, so the caller must make sure nothing else
+	// uses the isolate while the compiler does.
+	Isolate *v8go.Isolate
+}
+
+// jitFlagsOnce applies the flags disabling the optimizing tiers only once.
+var jitFlagsOnce sync.Once
+
+// pendingV8Flags holds the flags passed to SetV8Flags until the next compiler
+// instance is created.
+var pendingV8Flags struct {
+	sync.Mutex
+	flags []string
+}
+
+// SetV8Flags sets V8 flags such as "--stack-size=4000" or
+// "--max-old-space-size=4096", separated by whitespace. The flags are applied
+// when the next compiler instance is created, before its isolate is.
+//
+// V8 flags are process-global and cannot be reverted: once applied they
+// affect every isolate created afterwards in the process, including those of
+// other packages using V8, and changing them while isolates are running is
+// not supported by V8. Call it once at startup, before creating compilers.
+func SetV8Flags(flags string) {
+	pendingV8Flags.Lock()
+	defer pendingV8Flags.Unlock()
+	pendingV8Flags.flags = append(pendingV8Flags.flags, strings.Fields(flags)...)
+}
+
+// applyV8Flags applies the flags passed to SetV8Flags, if any.
+func applyV8Flags() {
+	pendingV8Flags.Lock()
+	defer pendingV8Flags.Unlock()
+	if len(pendingV8Flags.flags) > 0 {
+		v8go.SetFlags(pendingV8Flags.flags...)
+		pendingV8Flags.flags = nil
+	}
 }
 
 // New creates a new Solc binding using the provided soljson.js emscripten binary.
 func New(soljsonjs string) (Solc, error) {
-	return newBaseSolc(soljsonjs)
+	return newBaseSolc(soljsonjs, nil)
+}
+
+// NewWithOptions creates a new Solc binding like New, configured by options.
+// Pass nil for options to use the defaults.
+func NewWithOptions(soljsonjs string, options *InstanceOptions) (Solc, error) {
+	return newBaseSolc(soljsonjs, options)
 }
 
 // newBaseSolc creates and initializes a new baseSolc instance.
-func newBaseSolc(soljsonjs string) (*baseSolc, error) {
+func newBaseSolc(soljsonjs string, options *InstanceOptions) (*baseSolc, error) {
 	if soljsonjs == "" {
-		return nil, fmt.Errorf("soljsonjs cannot be empty")
+		return nil, &InitError{Err: errors.New("soljsonjs cannot be empty")}
+	}
+	applyV8Flags()
+	if options != nil && options.DisableJIT {
+		jitFlagsOnce.Do(func() {
+			v8go.SetFlags("--no-opt", "--no-wasm-tier-up")
+		})
 	}
 	// Create v8go JS execution context
-	isolate := v8go.NewIsolate()
-	ctx := v8go.NewContext(isolate)
+	ownsIsolate := options == nil || options.Isolate == nil
+	var isolate *v8go.Isolate
+	if ownsIsolate {
+		var err error
+		if isolate, err = createIsolate(); err != nil {
+			return nil, &InitError{Err: err}
+		}
+	} else {
+		isolate = options.Isolate
+	}
+	ctx, err := createContext(isolate)
+	if err != nil {
+		if ownsIsolate {
+			isolate.Dispose()
+		}
+		return nil, &InitError{Err: err}
+	}
 
 	// Create Solc object
 	solc := &baseSolc{
-		isolate: isolate,
-		ctx:     ctx,
+		isolate:     isolate,
+		ctx:         ctx,
+		ownsIsolate: ownsIsolate,
 	}
 
 	// Initialize solc
 	if err := solc.init(soljsonjs); err != nil {
 		solc.cleanup()
-		return nil, fmt.Errorf("failed to initialize compiler: %w", err)
+		return nil, &InitError{Err: err}
 	}
 
 	return solc, nil
 }
 
+// newIsolate and newContext allocate V8 isolates and contexts. They are
+// variables so that tests can simulate allocation failures.
+var (
+	newIsolate = v8go.NewIsolate
+	newContext = func(isolate *v8go.Isolate) *v8go.Context { return v8go.NewContext(isolate) }
+)
+
+// createIsolate creates a V8 isolate, turning a nil result or a panic, e.g.
+// under memory pressure, into an error.
+func createIsolate() (isolate *v8go.Isolate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			isolate, err = nil, fmt.Errorf("failed to create V8 isolate: %v", r)
+		}
+	}()
+
+	if isolate = newIsolate(); isolate == nil {
+		return nil, fmt.Errorf("failed to create V8 isolate")
+	}
+	return isolate, nil
+}
+
+// createContext creates a V8 context in the isolate, turning a nil result or
+// a panic into an error.
+func createContext(isolate *v8go.Isolate) (ctx *v8go.Context, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ctx, err = nil, fmt.Errorf("failed to create V8 context: %v", r)
+		}
+	}()
+
+	if ctx = newContext(isolate); ctx == nil {
+		return nil, fmt.Errorf("failed to create V8 context")
+	}
+	return ctx, nil
+}
+
+// polyfillScript defines the globals that some soljson.js builds reference
+// but a bare V8 context lacks: self, performance.now and console, which logs
+// through debugLog. Existing definitions are kept. window is deliberately not
+// defined, as Emscripten would then assume a browser environment.
+const polyfillScript = `
+	if (typeof globalThis.self === 'undefined') {
+		globalThis.self = globalThis;
+	}
+	if (typeof globalThis.performance === 'undefined') {
+		globalThis.performance = {};
+	}
+	if (typeof globalThis.performance.now !== 'function') {
+		var polyfillStart = Date.now();
+		globalThis.performance.now = function() { return Date.now() - polyfillStart; };
+	}
+	if (typeof globalThis.console === 'undefined') {
+		globalThis.console = {};
+	}
+	['log', 'info', 'warn', 'error', 'debug'].forEach(function(level) {
+		if (typeof globalThis.console[level] !== 'function') {
+			globalThis.console[level] = function() {
+				debugLog(Array.prototype.join.call(arguments, ' '));
+			};
+		}
+	});
+`
+
 // init initializes the Solidity compiler by executing the soljson.js script
 // and binding the necessary functions.
 func (s *baseSolc) init(soljsonjs string) error {
-	// Execute soljson.js script
-	if _, err := s.ctx.RunScript(soljsonjs, "soljson.js"); err != nil {
-		return fmt.Errorf("failed to execute soljson.js: %w", err)
-	}
-
 	// Set up debug logging function
 	debugLogFunc := v8go.NewFunctionTemplate(s.isolate, func(info *v8go.FunctionCallbackInfo) *v8go.Value {
 		args := info.Args()
 		if len(args) > 0 {
-			if os.Getenv("SOLC_DEBUG") == "1" {
-				fmt.Printf("JS DEBUG: %s\n", args[0].String())
-			}
+			debugf("JS DEBUG: %s\n", args[0].String())
 		}
 		return v8go.Undefined(s.isolate)
 	})
@@ -108,6 +407,14 @@ func (s *baseSolc) init(soljsonjs string) error {
 		return fmt.Errorf("failed to set debug function: %w", err)
 	}
 
+	// Provide the browser globals some builds expect, then execute soljson.js
+	if _, err := s.ctx.RunScript(polyfillScript, "polyfills.js"); err != nil {
+		return fmt.Errorf("failed to install polyfills: %w", err)
+	}
+	if _, err := s.ctx.RunScript(soljsonjs, "soljson.js"); err != nil {
+		return fmt.Errorf("failed to execute soljson.js: %w", err)
+	}
+
 	// Bind version function
 	versionFunc := "version"
 	if strings.Contains(soljsonjs, "_solidity_version") {
@@ -123,26 +430,27 @@ func (s *baseSolc) init(soljsonjs string) error {
 		return fmt.Errorf("version binding is not a function: %w", err)
 	}
 
-	// Bind license function
+	// Read the license, which old builds do not export
+	licenseFunc := ""
 	if strings.Contains(soljsonjs, "_solidity_license") {
-		licenseVal, err := s.ctx.RunScript("Module.cwrap('solidity_license', 'string', [])", "wrap_license.js")
-		if err != nil {
-			return fmt.Errorf("failed to bind license function: %w", err)
-		}
-		s.license, err = licenseVal.AsFunction()
-		if err != nil {
-			return fmt.Errorf("license binding is not a function: %w", err)
-		}
+		licenseFunc = "solidity_license"
 	} else if strings.Contains(soljsonjs, "_license") {
-		licenseVal, err := s.ctx.RunScript("Module.cwrap('license', 'string', [])", "wrap_license.js")
-		if err != nil {
-			return fmt.Errorf("failed to bind license function: %w", err)
-		}
-		s.license, err = licenseVal.AsFunction()
+		licenseFunc = "license"
+	}
+	if licenseFunc != "" {
+		licenseVal, err := s.ctx.RunScript(fmt.Sprintf("Module.cwrap('%s', 'string', [])()", licenseFunc), "wrap_license.js")
 		if err != nil {
-			return fmt.Errorf("license binding is not a function: %w", err)
+			return fmt.Errorf("failed to get compiler license: %w", err)
 		}
+		s.license = licenseVal.String()
+	}
+
+	versionResult, err := s.version.Call(v8go.Undefined(s.isolate))
+	if err != nil {
+		return fmt.Errorf("failed to get compiler version: %w", err)
 	}
+	s.versionString = versionResult.String()
+	s.shortVersion, s.longVersion = parseCompilerVersion(s.versionString)
 
 	// Simple wrapper for basic compilation
 	setupScript := `
@@ -189,6 +497,9 @@ func (s *baseSolc) Close() error {
 		return nil
 	}
 
+	if s.stopCloseOnDone != nil {
+		s.stopCloseOnDone()
+	}
 	s.cleanup()
 	s.closed = true
 	return nil
@@ -201,49 +512,175 @@ func (s *baseSolc) cleanup() {
 		s.ctx = nil
 	}
 	if s.isolate != nil {
-		s.isolate.Dispose()
+		if s.ownsIsolate {
+			s.isolate.Dispose()
+		}
 		s.isolate = nil
 	}
 }
 
-// License returns the license information of the compiler.
+// License returns the license information of the compiler, cached at init.
 func (s *baseSolc) License() string {
-	if s.license == nil {
-		return ""
+	return s.license
+}
+
+// Version returns the version information of the compiler, cached at init.
+func (s *baseSolc) Version() string {
+	return s.versionString
+}
+
+// ShortVersion returns the release version of the compiler, e.g. "0.8.21".
+func (s *baseSolc) ShortVersion() string {
+	return s.shortVersion
+}
+
+// LongVersion returns the commit-qualified version of the compiler, e.g. "0.8.21+commit.d9974bed".
+func (s *baseSolc) LongVersion() string {
+	return s.longVersion
+}
+
+// WithContext calls fn with the isolate and context while holding the lock.
+func (s *baseSolc) WithContext(fn func(isolate *v8go.Isolate, ctx *v8go.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
 	}
 
+	return fn(s.isolate, s.ctx)
+}
+
+// Ping calls into the compiler and checks that it still reports the version it
+// was initialized with. It is cheap enough to run before handing out a pooled instance.
+func (s *baseSolc) Ping() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.closed {
-		return ""
+		return ErrClosed
 	}
 
-	val, err := s.license.Call(v8go.Undefined(s.ctx.Isolate()))
+	val, err := s.version.Call(v8go.Undefined(s.isolate))
 	if err != nil {
-		return ""
+		return fmt.Errorf("failed to call compiler: %w", err)
+	}
+	if _, long := parseCompilerVersion(val.String()); long != s.longVersion {
+		return fmt.Errorf("compiler reported unexpected version %q", val.String())
 	}
-	return val.String()
+
+	return nil
 }
 
-// Version returns the version information of the compiler.
-func (s *baseSolc) Version() string {
-	if s.version == nil {
-		return ""
+// Supports reports whether the compiler version supports the given feature.
+func (s *baseSolc) Supports(feature string) bool {
+	return versionSupports(s.shortVersion, feature)
+}
+
+// DefaultEVMVersion returns the default EVM version of the compiler version.
+func (s *baseSolc) DefaultEVMVersion() string {
+	return defaultEVMVersion(s.shortVersion)
+}
+
+// trimMemoryScript resets the compiler, which frees the output and state it
+// keeps from the last compilation. Builds without solidity_reset keep them.
+const trimMemoryScript = `
+	if (typeof Module['_solidity_reset'] === 'function') {
+		Module['_solidity_reset']();
 	}
+`
+
+// TrimMemory frees the output and state the native compiler keeps from the
+// last compilation by calling solidity_reset, so the WebAssembly heap can be
+// reused. The WebAssembly memory itself never shrinks, and v8go offers no way
+// to force a garbage collection, so JavaScript garbage is still collected on
+// V8's own schedule. Recycle the instance if its heap keeps growing.
+func (s *baseSolc) TrimMemory() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if _, err := s.ctx.RunScript(trimMemoryScript, "trim_memory.js"); err != nil {
+		return fmt.Errorf("failed to trim memory: %w", err)
+	}
+	return nil
+}
 
+// HeapStats returns the heap statistics of the V8 isolate. The WebAssembly
+// memory of the compiler is not part of the JavaScript heap; it is included
+// in ExternalMemory. For an isolate shared through InstanceOptions.Isolate,
+// the statistics cover all of its contexts.
+func (s *baseSolc) HeapStats() (v8go.HeapStatistics, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.closed {
-		return ""
+		return v8go.HeapStatistics{}, ErrClosed
+	}
+	return s.isolate.GetHeapStatistics(), nil
+}
+
+// exportNamePattern matches valid emscripten export names
+var exportNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CallExport wraps the named export with Module.cwrap and calls it with the given
+// string arguments. returnType is one of "string", "number", "boolean" or "" for
+// exports returning nothing. The call is serialized with compilations, but is
+// otherwise unchecked, so use it only for exports you know to be safe.
+func (s *baseSolc) CallExport(name string, returnType string, args ...string) (string, error) {
+	if !exportNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid export name: %q", name)
 	}
 
-	val, err := s.version.Call(v8go.Undefined(s.ctx.Isolate()))
+	jsReturnType := "null"
+	switch returnType {
+	case "string", "number", "boolean":
+		jsReturnType = fmt.Sprintf("'%s'", returnType)
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported return type: %q", returnType)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return "", ErrClosed
+	}
+
+	argTypes := strings.TrimSuffix(strings.Repeat("'string',", len(args)), ",")
+	script := fmt.Sprintf(`typeof Module['_%s'] === 'function' ? Module.cwrap('%s', %s, [%s]) : undefined`, name, name, jsReturnType, argTypes)
+	fnVal, err := s.ctx.RunScript(script, "wrap_export.js")
+	if err != nil {
+		return "", fmt.Errorf("failed to bind export %s: %w", name, err)
+	}
+	if fnVal.IsUndefined() {
+		return "", fmt.Errorf("export %s not found", name)
+	}
+	fn, err := fnVal.AsFunction()
 	if err != nil {
-		return ""
+		return "", fmt.Errorf("export %s binding is not a function: %w", name, err)
 	}
-	return val.String()
+
+	argVals := make([]v8go.Valuer, len(args))
+	for i, arg := range args {
+		argVal, err := v8go.NewValue(s.isolate, arg)
+		if err != nil {
+			return "", fmt.Errorf("failed to create argument value: %w", err)
+		}
+		argVals[i] = argVal
+	}
+
+	result, err := fn.Call(v8go.Undefined(s.isolate), argVals...)
+	if err != nil {
+		return "", fmt.Errorf("failed to call export %s: %w", name, err)
+	}
+	if result.IsNull() || result.IsUndefined() {
+		return "", nil
+	}
+	return result.String(), nil
 }
 
 // CompileWithOptions compiles Solidity source code with additional options like import callbacks.
@@ -252,64 +689,143 @@ func (s *baseSolc) CompileWithOptions(input *Input, options *CompileOptions) (*O
 		return nil, fmt.Errorf("input cannot be nil")
 	}
 
-	// Marshal Solc Compiler Input
-	inputJSON, err := json.Marshal(input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal input: %w", err)
-	}
-
 	// Run Compilation
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.closed {
-		return nil, fmt.Errorf("compiler has been closed")
+		return nil, ErrClosed
 	}
 
 	// Resolve imports if callback is provided
-	if options != nil && options.ImportCallback != nil {
-		resolver := newImportResolver(options.ImportCallback)
+	if callback := options.importCallback(); callback != nil {
+		resolver := newImportResolver(callback)
+		resolver.configure(options)
 
 		var err error
 		input, err = resolver.resolveImports(input)
+		if options.Stats != nil {
+			options.Stats.ImportsResolved = resolver.callbackCount
+		}
 		if err != nil {
-			return nil, fmt.Errorf("import resolution failed: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrImportFailed, err)
 		}
-
-		// Re-marshal the updated input
-		inputJSON, err = json.Marshal(input)
+	} else if options == nil || !options.AllowMissingImports {
+		missing, err := newImportResolver(nil).findMissingImports(input)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal updated input: %w", err)
+			return nil, err
 		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("%w: imports not found in sources and no import callback configured: %s", ErrImportFailed, strings.Join(missing, ", "))
+		}
+	}
+
+	// Select the common artifacts if the input selects nothing. With
+	// StopAfter no artifacts can be produced, so nothing is selected.
+	if len(input.Settings.OutputSelection) == 0 && input.Settings.StopAfter == "" && (options == nil || !options.NoDefaultOutputSelection) {
+		withDefault := *input
+		withDefault.Settings.OutputSelection = DefaultOutputSelection()
+		input = &withDefault
+	}
+
+	// Normalize source encoding if requested
+	if options != nil && (options.StripBOM || options.NormalizeLineEndings) {
+		input = normalizeSources(input, options.StripBOM, options.NormalizeLineEndings)
+	}
+	if options != nil && options.DefaultSPDX != "" {
+		input = addDefaultSPDX(input, options.DefaultSPDX)
+	}
+
+	// Expand glob patterns in the output selection if requested
+	if options != nil && options.ExpandSelectionPatterns && hasSelectionPatterns(input.Settings.OutputSelection) {
+		var err error
+		if input, err = s.expandOutputSelection(input); err != nil {
+			return nil, err
+		}
+	}
+
+	// Marshal Solc Compiler Input
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+	if options != nil && options.CaptureInput != nil {
+		*options.CaptureInput = string(inputJSON)
+	}
+
+	// Execute compilation
+	start := time.Now()
+	outputJSON, err := s.compileJSON(inputJSON)
+	if err != nil {
+		return nil, err
 	}
 
+	if options != nil && options.Stats != nil {
+		options.Stats.CompileDuration = time.Since(start)
+		options.Stats.InputSize = len(inputJSON)
+		options.Stats.OutputSize = len(outputJSON)
+	}
+
+	output, err := ParseOutput(outputJSON)
+	if err != nil {
+		return nil, err
+	}
+	if options != nil {
+		return output, diagnosticsError(output, options.StrictWarnings, options.FailFast)
+	}
+	return output, nil
+}
+
+// CompileWithResult compiles like CompileWithOptions and records the compiler
+// and EVM versions in the result. Like CompileWithOptions, it returns the
+// result together with the error in strict and fail-fast modes.
+func (s *baseSolc) CompileWithResult(input *Input, options *CompileOptions) (*CompileResult, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input cannot be nil")
+	}
+
+	evmVersion := input.Settings.EVMVersion
+	if evmVersion == "" {
+		evmVersion = s.DefaultEVMVersion()
+	}
+
+	output, err := s.CompileWithOptions(input, options)
+	if output == nil {
+		return nil, err
+	}
+	return &CompileResult{
+		Output:          output,
+		CompilerVersion: s.longVersion,
+		EVMVersion:      evmVersion,
+	}, err
+}
+
+// compileJSON runs the compiler on a standard JSON input without acquiring the mutex.
+// The input, including all resolved imports, is passed as a single V8 string
+// argument rather than interpolated into a script, so contents of any size and
+// character set reach the compiler verbatim without a script being compiled.
+func (s *baseSolc) compileJSON(inputJSON []byte) (string, error) {
 	// Get the compile function
 	compileVal, err := s.ctx.Global().Get("compile")
 	if err != nil {
-		return nil, fmt.Errorf("compile function not available: %w", err)
+		return "", fmt.Errorf("compile function not available: %w", err)
 	}
 
 	compileFunc, err := compileVal.AsFunction()
 	if err != nil {
-		return nil, fmt.Errorf("compile is not a function: %w", err)
+		return "", fmt.Errorf("compile is not a function: %w", err)
 	}
 
 	// Create input value
 	valInput, err := v8go.NewValue(s.ctx.Isolate(), string(inputJSON))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create input value: %w", err)
+		return "", fmt.Errorf("failed to create input value: %w", err)
 	}
 
-	// Execute compilation
 	valOutput, err := compileFunc.Call(v8go.Undefined(s.ctx.Isolate()), valInput)
 	if err != nil {
-		return nil, fmt.Errorf("compilation failed: %w", err)
+		return "", fmt.Errorf("compilation failed: %w", err)
 	}
 
-	output := &Output{}
-	if err := json.Unmarshal([]byte(valOutput.String()), output); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
-	}
-
-	return output, nil
+	return valOutput.String(), nil
 }
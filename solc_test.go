@@ -1,14 +1,20 @@
 package solc
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"rogchap.com/v8go"
 )
 
 type args struct {
@@ -480,8 +486,7 @@ func TestVersionResolution(t *testing.T) {
 
 	// Test invalid version
 	_, err = resolveVersion("invalid.version")
-	assert.Error(t, err, "Should error for invalid version")
-	assert.Contains(t, err.Error(), "not found", "Error should mention version not found")
+	assert.ErrorIs(t, err, ErrVersionNotFound, "Should error for invalid version")
 }
 
 func TestVersionListFetching(t *testing.T) {
@@ -953,7 +958,7 @@ func TestOpenZeppelin(t *testing.T) {
 
 			options := &CompileOptions{
 				ImportCallback: func(url string) ImportResult {
-					fmt.Println("Importing", url)
+					t.Logf("Importing %s", url)
 					var filePath string
 
 					// Handle OpenZeppelin imports
@@ -985,3 +990,586 @@ func TestOpenZeppelin(t *testing.T) {
 	}
 
 }
+
+func TestCachedVersionAndLicense(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	// The cached values match live calls into the binary
+	version, err := compiler.CallExport("solidity_version", "string")
+	require.NoError(t, err)
+	assert.Equal(t, version, compiler.Version())
+	license, err := compiler.CallExport("solidity_license", "string")
+	require.NoError(t, err)
+	assert.Equal(t, license, compiler.License())
+
+	// They are returned without waiting for the compiler's lock
+	err = compiler.WithContext(func(isolate *v8go.Isolate, ctx *v8go.Context) error {
+		done := make(chan string, 1)
+		go func() { done <- compiler.Version() + compiler.License() }()
+		select {
+		case result := <-done:
+			assert.Equal(t, version+license, result)
+		case <-time.After(5 * time.Second):
+			t.Error("Version and License should not block on the compiler lock")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestCallExport(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	version, err := compiler.CallExport("solidity_version", "string")
+	require.NoError(t, err)
+	assert.Equal(t, compiler.Version(), version)
+
+	_, err = compiler.CallExport("solidity_reset", "")
+	assert.NoError(t, err)
+
+	_, err = compiler.CallExport("does_not_exist", "string")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = compiler.CallExport("solidity_version'); evil('", "string")
+	assert.ErrorContains(t, err, "invalid export name")
+
+	compiler.Close()
+	_, err = compiler.CallExport("solidity_version", "string")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestSentinelErrors(t *testing.T) {
+	_, err := New("")
+	assert.ErrorIs(t, err, ErrCompilerInit)
+
+	_, err = New("throw new Error('broken binary')")
+	assert.ErrorIs(t, err, ErrCompilerInit)
+
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"main.sol": {Content: `pragma solidity ^0.8.0; import "./missing.sol"; contract A {}`},
+		},
+	}
+	_, err = compiler.CompileWithOptions(input, &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			return ImportResult{Error: "file not found"}
+		},
+	})
+	assert.ErrorIs(t, err, ErrImportFailed)
+	assert.ErrorContains(t, err, "missing.sol")
+
+	compiler.Close()
+	_, err = compiler.CompileWithOptions(input, nil)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, compiler.Ping(), ErrClosed)
+}
+
+func TestV8AllocationFailure(t *testing.T) {
+	soljson, ok := getEmbeddedBinary("0.8.21")
+	require.True(t, ok)
+
+	originalIsolate, originalContext := newIsolate, newContext
+	defer func() { newIsolate, newContext = originalIsolate, originalContext }()
+
+	newIsolate = func() *v8go.Isolate { return nil }
+	_, err := New(soljson)
+	assert.ErrorIs(t, err, ErrCompilerInit)
+	assert.ErrorContains(t, err, "V8 isolate")
+
+	newIsolate = func() *v8go.Isolate { panic("out of memory") }
+	_, err = New(soljson)
+	assert.ErrorIs(t, err, ErrCompilerInit)
+	assert.ErrorContains(t, err, "out of memory")
+
+	newIsolate = originalIsolate
+	newContext = func(*v8go.Isolate) *v8go.Context { return nil }
+	_, err = New(soljson)
+	assert.ErrorIs(t, err, ErrCompilerInit)
+	assert.ErrorContains(t, err, "V8 context")
+
+	// A caller's isolate is left alive when the context cannot be created
+	isolate := v8go.NewIsolate()
+	defer isolate.Dispose()
+	_, err = NewWithOptions(soljson, &InstanceOptions{Isolate: isolate})
+	assert.ErrorIs(t, err, ErrCompilerInit)
+
+	newContext = originalContext
+	compiler, err := NewWithOptions(soljson, &InstanceOptions{Isolate: isolate})
+	require.NoError(t, err)
+	defer compiler.Close()
+	assert.Equal(t, "0.8.21", compiler.ShortVersion())
+}
+
+func TestStrictWarnings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	compile := func(content string) (*Output, error) {
+		return compiler.CompileWithOptions(&Input{
+			Language: "Solidity",
+			Sources:  map[string]SourceIn{"A.sol": {Content: content}},
+		}, &CompileOptions{StrictWarnings: true})
+	}
+	const unusedVariable = "// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\ncontract A { function f() public pure { uint256 unused; } }"
+
+	// Warnings fail the compilation, carrying the structured diagnostics
+	output, err := compile(unusedVariable)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrWarnings)
+	assert.NotNil(t, output, "The output should still be returned")
+	var diagnostics *DiagnosticsError
+	require.True(t, errors.As(err, &diagnostics))
+	require.NotEmpty(t, diagnostics.Diagnostics)
+	assert.True(t, diagnostics.Diagnostics[0].IsWarning())
+	assert.Contains(t, err.Error(), "Unused local variable")
+
+	// Errors take precedence over warnings
+	_, err = compile("pragma solidity ^0.8.0;\ncontract A { function f() public { uint256 unused; undefinedCall(); } }")
+	assert.ErrorIs(t, err, ErrCompilationFailed)
+	assert.NotErrorIs(t, err, ErrWarnings)
+	require.True(t, errors.As(err, &diagnostics))
+	assert.True(t, diagnostics.Diagnostics[0].IsError())
+
+	// Clean sources pass
+	_, err = compile("// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\ncontract A {}")
+	assert.NoError(t, err)
+
+	// Cached outputs are checked as well
+	input := &Input{Language: "Solidity", Sources: map[string]SourceIn{"A.sol": {Content: unusedVariable}}}
+	for i := 0; i < 2; i++ {
+		stats := &CompileStats{}
+		_, err = CompileCached(compiler, input, &CompileOptions{StrictWarnings: true, Stats: stats})
+		assert.ErrorIs(t, err, ErrWarnings)
+		assert.Equal(t, i == 1, stats.CacheHit)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{"A.sol": {Content: "pragma solidity ^0.8.0;\ncontract A {\n" +
+			"    function f() public { first(); }\n" +
+			"    function g() public { second(); }\n}"}},
+	}
+
+	// Only the first error is surfaced, while the output keeps all of them
+	output, err := compiler.CompileWithOptions(input.Clone(), &CompileOptions{FailFast: true})
+	require.ErrorIs(t, err, ErrCompilationFailed)
+	var diagnostics *DiagnosticsError
+	require.True(t, errors.As(err, &diagnostics))
+	require.Len(t, diagnostics.Diagnostics, 1)
+	assert.NotContains(t, err.Error(), "more")
+	var errs []Error
+	for _, e := range output.Errors {
+		if e.IsError() {
+			errs = append(errs, e)
+		}
+	}
+	require.Len(t, errs, 2, "solc still reports every error")
+	assert.Equal(t, errs[0], diagnostics.Diagnostics[0])
+
+	// Warnings alone do not fail
+	_, err = compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"A.sol": {Content: "pragma solidity ^0.8.0;\ncontract A {}"}},
+	}, &CompileOptions{FailFast: true})
+	assert.NoError(t, err)
+
+	// Cached outputs are checked as well
+	for i := 0; i < 2; i++ {
+		stats := &CompileStats{}
+		_, err = CompileCached(compiler, input, &CompileOptions{FailFast: true, Stats: stats})
+		assert.ErrorIs(t, err, ErrCompilationFailed)
+		assert.Equal(t, i == 1, stats.CacheHit)
+	}
+}
+
+func TestPolyfills(t *testing.T) {
+	// A minimal build that touches the browser globals while loading
+	fakeSoljson := `
+		var loadStarted = performance.now();
+		console.log('loading');
+		self.Module = {
+			cwrap: function(name) {
+				if (name === 'solidity_version') {
+					return function() { return '0.8.21+commit.d9974bed'; };
+				}
+				return function() { return '{}'; };
+			}
+		};
+		// references _solidity_version
+	`
+	compiler, err := New(fakeSoljson)
+	require.NoError(t, err)
+	defer compiler.Close()
+	assert.Equal(t, "0.8.21", compiler.ShortVersion())
+
+	// Existing definitions are not overridden
+	err = compiler.WithContext(func(isolate *v8go.Isolate, ctx *v8go.Context) error {
+		if _, err := ctx.RunScript("globalThis.performance = { now: function() { return 42; } };", "define.js"); err != nil {
+			return err
+		}
+		if _, err := ctx.RunScript(polyfillScript, "polyfills.js"); err != nil {
+			return err
+		}
+		val, err := ctx.RunScript("performance.now() + ':' + (self === globalThis)", "check.js")
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, "42:true", val.String())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestCompileStats(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: contractWithMultipleImports},
+		},
+		Settings: Settings{
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": []string{"abi", "evm.bytecode"}},
+			},
+		},
+	}
+
+	stats := &CompileStats{}
+	options := &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			switch url {
+			case "lib/Math.sol":
+				return ImportResult{Contents: mathLibrary}
+			case "lib/String.sol":
+				return ImportResult{Contents: stringLibrary}
+			}
+			return ImportResult{Error: fmt.Sprintf("File not found: %s", url)}
+		},
+		Stats: stats,
+	}
+
+	output, err := compiler.CompileWithOptions(input, options)
+	require.NoError(t, err)
+	assert.NotEmpty(t, output.Contracts)
+
+	assert.Equal(t, 2, stats.ImportsResolved)
+	assert.Greater(t, stats.CompileDuration, time.Duration(0))
+	assert.Greater(t, stats.InputSize, len(contractWithMultipleImports))
+	assert.Greater(t, stats.OutputSize, 0)
+}
+
+func TestCaptureInput(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Calculator.sol": {Content: contractWithMultipleImports},
+		},
+	}
+
+	var captured string
+	output, err := compiler.CompileWithOptions(input.Clone(), &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			switch url {
+			case "lib/Math.sol":
+				return ImportResult{Contents: mathLibrary}
+			case "lib/String.sol":
+				return ImportResult{Contents: stringLibrary}
+			}
+			return ImportResult{Error: fmt.Sprintf("File not found: %s", url)}
+		},
+		DefaultSPDX:  "MIT",
+		CaptureInput: &captured,
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+
+	// The captured input contains the resolved imports and the injected defaults
+	var sent Input
+	require.NoError(t, json.Unmarshal([]byte(captured), &sent))
+	assert.Contains(t, sent.Sources, "lib/Math.sol")
+	assert.Contains(t, sent.Sources, "lib/String.sol")
+	assert.Equal(t, DefaultOutputSelection(), sent.Settings.OutputSelection)
+
+	// Compiling the captured input on its own reproduces the output
+	replayed, err := compiler.CompileWithOptions(&sent, nil)
+	require.NoError(t, err)
+	assert.Equal(t, output, replayed)
+}
+
+func TestCompileWithResult(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	input := &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Greeter.sol": {Content: constructorContract}},
+	}
+	result, err := compiler.CompileWithResult(input.Clone(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output.Contracts["Greeter.sol"], "Greeter")
+	assert.Equal(t, "0.8.21+commit.d9974bed", result.CompilerVersion)
+	assert.Equal(t, "shanghai", result.EVMVersion, "The default EVM version should be recorded")
+
+	paris := input.Clone()
+	paris.Settings.EVMVersion = "paris"
+	result, err = compiler.CompileWithResult(paris, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "paris", result.EVMVersion)
+
+	// Strict mode still returns the result with the error
+	result, err = compiler.CompileWithResult(&Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"A.sol": {Content: "pragma solidity ^0.8.0; contract A {}"}},
+	}, &CompileOptions{StrictWarnings: true})
+	assert.ErrorIs(t, err, ErrWarnings)
+	require.NotNil(t, result)
+	assert.NotEmpty(t, result.Output.Errors)
+
+	_, err = compiler.CompileWithResult(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestPing(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+
+	assert.NoError(t, compiler.Ping())
+
+	// A broken binding is detected
+	base := compiler.(*baseSolc)
+	broken, err := base.ctx.RunScript("(function() { throw new Error('corrupt'); })", "corrupt.js")
+	require.NoError(t, err)
+	base.version, err = broken.AsFunction()
+	require.NoError(t, err)
+	assert.Error(t, compiler.Ping())
+
+	compiler.Close()
+	assert.ErrorContains(t, compiler.Ping(), "closed")
+}
+
+func TestTrimMemory(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	// Count the resets performed on the native compiler
+	require.NoError(t, compiler.WithContext(func(isolate *v8go.Isolate, ctx *v8go.Context) error {
+		_, err := ctx.RunScript(`
+			var resets = 0;
+			var originalReset = Module['_solidity_reset'];
+			Module['_solidity_reset'] = function() { resets++; return originalReset(); };
+		`, "spy.js")
+		return err
+	}))
+
+	input := &Input{
+		Language: "Solidity",
+		Sources:  map[string]SourceIn{"Greeter.sol": {Content: constructorContract}},
+	}
+	var first, last *Output
+	var firstHeap uint64
+	for i := 0; i < 10; i++ {
+		output, err := compiler.CompileWithOptions(input.Clone(), nil)
+		require.NoError(t, err)
+		require.NoError(t, compiler.TrimMemory())
+
+		if i == 0 {
+			first = output
+			stats, err := compiler.HeapStats()
+			require.NoError(t, err)
+			firstHeap = stats.UsedHeapSize
+		}
+		last = output
+	}
+	assert.Equal(t, first, last, "Compilations after a reset should be unaffected")
+	stats, err := compiler.HeapStats()
+	require.NoError(t, err)
+	assert.Less(t, stats.UsedHeapSize, 2*firstHeap, "Heap should not grow across compilations")
+
+	require.NoError(t, compiler.WithContext(func(isolate *v8go.Isolate, ctx *v8go.Context) error {
+		resets, err := ctx.RunScript("resets", "resets.js")
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), resets.Int32())
+		return nil
+	}))
+
+	compiler.Close()
+	assert.ErrorIs(t, compiler.TrimMemory(), ErrClosed)
+}
+
+func TestHeapStats(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+
+	stats, err := compiler.HeapStats()
+	require.NoError(t, err)
+	assert.Greater(t, stats.UsedHeapSize, uint64(0))
+	assert.GreaterOrEqual(t, stats.TotalHeapSize, stats.UsedHeapSize)
+	assert.Greater(t, stats.HeapSizeLimit, stats.TotalHeapSize)
+	assert.Greater(t, stats.ExternalMemory, uint64(16<<20), "The WebAssembly memory should count as external memory")
+
+	compiler.Close()
+	_, err = compiler.HeapStats()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestDisableJIT(t *testing.T) {
+	// V8 flags are process-wide, so run in a child process to keep the rest
+	// of the suite on the optimizing tiers
+	if os.Getenv("SOLC_TEST_DISABLE_JIT") != "1" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDisableJIT$")
+		cmd.Env = append(os.Environ(), "SOLC_TEST_DISABLE_JIT=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return
+	}
+
+	compile := func(options *InstanceOptions) string {
+		compiler, err := NewWithVersionOptions("0.8.21", options)
+		require.NoError(t, err)
+		defer compiler.Close()
+
+		output, err := compiler.CompileWithOptions(&Input{
+			Language: "Solidity",
+			Sources: map[string]SourceIn{
+				"Greeter.sol": {Content: constructorContract},
+			},
+			Settings: Settings{
+				OutputSelection: map[string]map[string][]string{
+					"*": {"*": []string{"evm.bytecode.object"}},
+				},
+			},
+		}, nil)
+		require.NoError(t, err)
+		require.False(t, output.HasErrors(), output.Errors)
+		return output.Contracts["Greeter.sol"]["Greeter"].EVM.Bytecode.Object
+	}
+
+	jit := compile(nil)
+	jitless := compile(&InstanceOptions{DisableJIT: true})
+	assert.NotEmpty(t, jitless)
+	assert.Equal(t, jit, jitless, "Bytecode should not depend on the execution tier")
+}
+
+func TestSetV8Flags(t *testing.T) {
+	// V8 flags are process-wide, so run in a child process
+	if os.Getenv("SOLC_TEST_V8_FLAGS") != "1" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSetV8Flags$")
+		cmd.Env = append(os.Environ(), "SOLC_TEST_V8_FLAGS=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return
+	}
+
+	SetV8Flags("  --stack-size=2000\t--max-old-space-size=384 ")
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+	assert.Empty(t, pendingV8Flags.flags, "Flags should be applied once")
+
+	stats, err := compiler.HeapStats()
+	require.NoError(t, err)
+	assert.Less(t, stats.HeapSizeLimit, uint64(512<<20), "The heap limit should follow --max-old-space-size")
+
+	output, err := compiler.CompileWithOptions(&Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			"Greeter.sol": {Content: constructorContract},
+		},
+	}, nil)
+	require.NoError(t, err)
+	assert.False(t, output.HasErrors(), output.Errors)
+}
+
+func TestWithContext(t *testing.T) {
+	binary, exists := getEmbeddedBinary("0.8.21")
+	require.True(t, exists)
+
+	// A caller-provided isolate outlives the compiler
+	isolate := v8go.NewIsolate()
+	defer isolate.Dispose()
+
+	compiler, err := NewWithOptions(binary, &InstanceOptions{Isolate: isolate})
+	require.NoError(t, err)
+
+	err = compiler.WithContext(func(iso *v8go.Isolate, ctx *v8go.Context) error {
+		assert.Same(t, isolate, iso)
+		_, err := ctx.RunScript("var helperValue = 42", "helper.js")
+		return err
+	})
+	require.NoError(t, err)
+
+	err = compiler.WithContext(func(iso *v8go.Isolate, ctx *v8go.Context) error {
+		val, err := ctx.RunScript("helperValue", "read_helper.js")
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, int32(42), val.Int32(), "Globals should persist in the compiler context")
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, compiler.Close())
+	err = compiler.WithContext(func(*v8go.Isolate, *v8go.Context) error { return nil })
+	assert.ErrorContains(t, err, "closed")
+
+	// The isolate is still usable after the compiler is closed
+	ctx := v8go.NewContext(isolate)
+	defer ctx.Close()
+	val, err := ctx.RunScript("1 + 1", "check.js")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), val.Int32())
+}
+
+func TestNewWithVersionContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	compiler, err := NewWithVersionContext(ctx, "0.8.21")
+	require.NoError(t, err)
+	require.NoError(t, compiler.Ping())
+
+	// Cancelling the context closes the compiler
+	cancel()
+	assert.Eventually(t, func() bool {
+		return compiler.Ping() != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ErrorContains(t, compiler.Ping(), "closed")
+	assert.NoError(t, compiler.Close(), "Closing again should be a no-op")
+
+	// Closing first releases the context callback
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	compiler, err = NewWithVersionContext(ctx, "0.8.21")
+	require.NoError(t, err)
+	require.NoError(t, compiler.Close())
+	assert.False(t, compiler.(*baseSolc).stopCloseOnDone(), "Callback should have been unregistered")
+
+	// A context that is already done is rejected
+	cancel()
+	_, err = NewWithVersionContext(ctx, "0.8.21")
+	assert.ErrorIs(t, err, context.Canceled)
+}
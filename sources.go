@@ -0,0 +1,310 @@
+package solc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirOptions configures how SourcesFromDir walks a directory tree.
+type DirOptions struct {
+	// Include lists glob patterns, relative to the root, of files to include.
+	// Patterns use forward slashes and "**" matches any number of directories.
+	// Defaults to all .sol files.
+	Include []string
+	// Exclude lists glob patterns of files and directories to skip.
+	Exclude []string
+	// IncludeHidden also walks directories whose name starts with a dot.
+	IncludeHidden bool
+	// IncludeNodeModules also walks node_modules directories.
+	IncludeNodeModules bool
+	// Language is the language of the sources, "Solidity" or "Yul". It
+	// overrides the detection by file extension in InputFromDir, and with
+	// "Yul" the default Include selects .yul instead of .sol files.
+	Language string
+}
+
+// FileImportCallback is an ImportCallback reading imports from the local
+// filesystem, with URLs taken as slash-separated paths relative to the
+// working directory. It reads any file the process can; use DirImportCallback
+// to restrict imports to known directories.
+func FileImportCallback(url string) ImportResult {
+	content, err := os.ReadFile(filepath.FromSlash(url))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ImportResult{Error: "File not found"}
+	}
+	if err != nil {
+		return ImportResult{Error: err.Error()}
+	}
+	return ImportResult{Contents: string(content)}
+}
+
+// DirImportCallback returns an ImportCallback reading imports from the local
+// filesystem like FileImportCallback, but only files under one of dirs, like
+// solc's --allow-paths. Paths are checked after resolving ".." and symbolic
+// links, so neither absolute paths nor links can reach files outside dirs.
+// This is the callback used when CompileOptions.IncludePaths is set without
+// an ImportCallback, with the base path and include paths as dirs.
+func DirImportCallback(dirs ...string) ImportCallback {
+	allowed := make([]string, len(dirs))
+	for i, dir := range dirs {
+		allowed[i] = realPath(dir)
+	}
+	return func(url string) ImportResult {
+		name := realPath(filepath.FromSlash(url))
+		for _, dir := range allowed {
+			if rel, err := filepath.Rel(dir, name); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return FileImportCallback(url)
+			}
+		}
+		return ImportResult{Error: "File outside of allowed directories"}
+	}
+}
+
+// realPath returns the absolute path of name with symbolic links resolved,
+// or just the cleaned absolute path if it does not exist.
+func realPath(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		return resolved
+	}
+	return filepath.Clean(name)
+}
+
+// FSImportCallback returns an ImportCallback reading imports from fsys, with
+// URLs taken as slash-separated paths within it.
+func FSImportCallback(fsys fs.FS) ImportCallback {
+	return func(url string) ImportResult {
+		name := path.Clean(url)
+		if !fs.ValidPath(name) {
+			return ImportResult{Error: "File not found"}
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return ImportResult{Error: "File not found"}
+		}
+		if err != nil {
+			return ImportResult{Error: err.Error()}
+		}
+		return ImportResult{Contents: string(content)}
+	}
+}
+
+// CompileFS compiles the files at the given slash-separated paths of fsys,
+// e.g. an embed.FS of template contracts, keyed by those paths. Their imports
+// are read from the same fsys, falling back to the ImportCallback of options
+// for files it does not contain, and BasePath and IncludePaths apply within
+// fsys. The language is detected from the file extensions. Pass nil for
+// options to use the defaults.
+func CompileFS(compiler Solc, fsys fs.FS, paths []string, options *CompileOptions) (*Output, error) {
+	if compiler == nil {
+		return nil, fmt.Errorf("compiler cannot be nil")
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to compile")
+	}
+
+	sources := make(map[string]SourceIn, len(paths))
+	for _, name := range paths {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source %s: %w", name, err)
+		}
+		sources[name] = SourceIn{Content: string(content)}
+	}
+	language, err := DetectLanguage(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	fsOptions := CompileOptions{}
+	if options != nil {
+		fsOptions = *options
+	}
+	fsCallback := FSImportCallback(fsys)
+	fallback := fsOptions.ImportCallback
+	fsOptions.ImportCallback = func(url string) ImportResult {
+		result := fsCallback(url)
+		if result.Error != "" && fallback != nil {
+			return fallback(url)
+		}
+		return result
+	}
+
+	return compiler.CompileWithOptions(&Input{Language: language, Sources: sources}, &fsOptions)
+}
+
+// languageExtensions maps source file extensions to standard JSON languages
+var languageExtensions = map[string]string{
+	".sol": "Solidity",
+	".yul": "Yul",
+}
+
+// LanguageForFile returns the standard JSON language of a source file by its
+// extension: "Solidity" for .sol and "Yul" for .yul files.
+func LanguageForFile(name string) (string, error) {
+	language, ok := languageExtensions[path.Ext(name)]
+	if !ok {
+		return "", fmt.Errorf("unknown language of source file %s, set the language explicitly", name)
+	}
+	return language, nil
+}
+
+// DetectLanguage returns the language shared by all sources according to
+// their file extensions. Sources with unknown extensions and mixes of
+// languages, which a single input cannot hold, are reported as errors.
+func DetectLanguage(sources map[string]SourceIn) (string, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	detected := ""
+	for _, name := range names {
+		language, err := LanguageForFile(name)
+		if err != nil {
+			return "", err
+		}
+		if detected != "" && language != detected {
+			return "", fmt.Errorf("sources mix %s and %s, which must be compiled separately", detected, language)
+		}
+		detected = language
+	}
+	if detected == "" {
+		return "", fmt.Errorf("no sources to detect the language from")
+	}
+	return detected, nil
+}
+
+// InputFromDir reads the sources under root like SourcesFromDir and returns
+// them as an input whose language is detected from the file extensions,
+// unless DirOptions.Language is set. Without Include patterns or a language,
+// both .sol and .yul files are read, and mixing them is an error.
+func InputFromDir(root string, options *DirOptions) (*Input, error) {
+	dirOptions := DirOptions{}
+	if options != nil {
+		dirOptions = *options
+	}
+	if len(dirOptions.Include) == 0 && dirOptions.Language == "" {
+		dirOptions.Include = []string{"**/*.sol", "**/*.yul"}
+	}
+
+	sources, err := SourcesFromDir(root, &dirOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	language := dirOptions.Language
+	if language == "" {
+		if language, err = DetectLanguage(sources); err != nil {
+			return nil, fmt.Errorf("failed to read sources from %s: %w", root, err)
+		}
+	}
+
+	return &Input{Language: language, Sources: sources}, nil
+}
+
+// SourcesFromDir reads all Solidity files (or Yul files, see DirOptions.Language)
+// under root into a sources map keyed by their slash-separated path relative
+// to root. Hidden directories and node_modules are skipped by default. Pass
+// nil for options to use defaults.
+func SourcesFromDir(root string, options *DirOptions) (map[string]SourceIn, error) {
+	if options == nil {
+		options = &DirOptions{}
+	}
+
+	defaultExtension := ".sol"
+	if options.Language == "Yul" {
+		defaultExtension = ".yul"
+	}
+
+	sources := make(map[string]SourceIn)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if (!options.IncludeHidden && strings.HasPrefix(name, ".")) ||
+				(!options.IncludeNodeModules && name == "node_modules") ||
+				matchAnyGlob(options.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matchAnyGlob(options.Exclude, rel) {
+			return nil
+		}
+		if len(options.Include) > 0 {
+			if !matchAnyGlob(options.Include, rel) {
+				return nil
+			}
+		} else if path.Ext(rel) != defaultExtension {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sources[rel] = SourceIn{Content: string(content)}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sources from %s: %w", root, err)
+	}
+
+	return sources, nil
+}
+
+// matchAnyGlob reports whether name matches any of the glob patterns.
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches path segments against pattern segments, where a "**"
+// segment matches zero or more path segments.
+func matchGlob(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
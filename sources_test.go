@@ -0,0 +1,209 @@
+package solc
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates the given files (slash-separated path -> content) under root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func TestSourcesFromDir(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"Token.sol":                     "contract Token {}",
+		"lib/Math.sol":                  mathLibrary,
+		"lib/deep/nested/Util.sol":      "library Util {}",
+		"test/Token.t.sol":              "contract TokenTest {}",
+		"README.md":                     "# readme",
+		".git/Hidden.sol":               "contract Hidden {}",
+		"node_modules/pkg/External.sol": "contract External {}",
+	})
+
+	sources, err := SourcesFromDir(root, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Token.sol", "lib/Math.sol", "lib/deep/nested/Util.sol", "test/Token.t.sol"}, keys(sources))
+	assert.Equal(t, mathLibrary, sources["lib/Math.sol"].Content)
+
+	sources, err = SourcesFromDir(root, &DirOptions{
+		Include: []string{"lib/**/*.sol"},
+		Exclude: []string{"lib/deep/**"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"lib/Math.sol"}, keys(sources))
+
+	sources, err = SourcesFromDir(root, &DirOptions{
+		Exclude:            []string{"**/*.t.sol"},
+		IncludeHidden:      true,
+		IncludeNodeModules: true,
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Token.sol", "lib/Math.sol", "lib/deep/nested/Util.sol", ".git/Hidden.sol", "node_modules/pkg/External.sol"}, keys(sources))
+
+	_, err = SourcesFromDir(filepath.Join(root, "missing"), nil)
+	assert.Error(t, err)
+}
+
+func TestInputFromDir(t *testing.T) {
+	solidity := t.TempDir()
+	writeTree(t, solidity, map[string]string{
+		"Token.sol":    "pragma solidity ^0.8.0; contract Token {}",
+		"lib/Math.sol": mathLibrary,
+	})
+	input, err := InputFromDir(solidity, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Solidity", input.Language)
+	assert.ElementsMatch(t, []string{"Token.sol", "lib/Math.sol"}, keys(input.Sources))
+
+	yul := t.TempDir()
+	writeTree(t, yul, map[string]string{
+		"Store.yul": `object "Store" { code { sstore(0, 42) } }`,
+	})
+	input, err = InputFromDir(yul, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Yul", input.Language)
+
+	// The detected language compiles
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+	input.Settings.OutputSelection = map[string]map[string][]string{"*": {"*": []string{"evm.bytecode.object"}}}
+	output, err := compiler.CompileWithOptions(input, nil)
+	require.NoError(t, err)
+	require.Empty(t, output.Errors)
+	assert.NotEmpty(t, output.Contracts["Store.yul"]["Store"].EVM.Bytecode.Object)
+
+	// Mixed languages and unknown extensions are rejected unless overridden
+	writeTree(t, yul, map[string]string{"Token.sol": "contract Token {}"})
+	_, err = InputFromDir(yul, nil)
+	assert.ErrorContains(t, err, "sources mix Yul and Solidity")
+	input, err = InputFromDir(yul, &DirOptions{Language: "Yul"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Store.yul"}, keys(input.Sources))
+
+	writeTree(t, solidity, map[string]string{"Token.vy": "# vyper"})
+	_, err = InputFromDir(solidity, &DirOptions{Include: []string{"**/*"}})
+	assert.ErrorContains(t, err, "unknown language of source file Token.vy")
+	input, err = InputFromDir(solidity, &DirOptions{Include: []string{"**/*"}, Language: "Solidity"})
+	require.NoError(t, err)
+	assert.Len(t, input.Sources, 3)
+
+	_, err = InputFromDir(t.TempDir(), nil)
+	assert.ErrorContains(t, err, "no sources")
+}
+
+func TestCompileFS(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	fsys := fstest.MapFS{
+		"templates/Token.sol": {Data: []byte(`pragma solidity ^0.8.0;
+import "./lib/Math.sol";
+import "@acme/Ownable.sol";
+contract Token is Ownable {}`)},
+		"templates/lib/Math.sol":         {Data: []byte(mathLibrary)},
+		"node_modules/@acme/Ownable.sol": {Data: []byte("pragma solidity ^0.8.0; contract Ownable {}")},
+	}
+
+	output, err := CompileFS(compiler, fsys, []string{"templates/Token.sol"}, &CompileOptions{
+		IncludePaths: []string{"node_modules"},
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	assert.NotEmpty(t, output.Contracts["templates/Token.sol"]["Token"].EVM.Bytecode.Object)
+	assert.Contains(t, output.Contracts, "templates/lib/Math.sol")
+	assert.Contains(t, output.Contracts, "@acme/Ownable.sol")
+
+	// Files missing from the FS fall back to the ImportCallback
+	delete(fsys, "node_modules/@acme/Ownable.sol")
+	var requested []string
+	output, err = CompileFS(compiler, fsys, []string{"templates/Token.sol"}, &CompileOptions{
+		ImportCallback: func(url string) ImportResult {
+			requested = append(requested, url)
+			return ImportResult{Contents: "pragma solidity ^0.8.0; contract Ownable {}"}
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, output.HasErrors(), "%v", output.Errors)
+	assert.Equal(t, []string{"@acme/Ownable.sol"}, requested)
+
+	_, err = CompileFS(compiler, fsys, []string{"templates/Missing.sol"}, nil)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestDirImportCallback(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"contracts/Token.sol": "contract Token {}",
+		"secret.txt":          "secret",
+	})
+	contracts := filepath.Join(root, "contracts")
+	secret := filepath.Join(root, "secret.txt")
+	callback := DirImportCallback(contracts)
+
+	result := callback(filepath.ToSlash(filepath.Join(contracts, "Token.sol")))
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "contract Token {}", result.Contents)
+	assert.Equal(t, "File not found", callback(filepath.ToSlash(filepath.Join(contracts, "Missing.sol"))).Error)
+
+	// Absolute paths and paths climbing out of the directories are rejected
+	for _, url := range []string{
+		filepath.ToSlash(secret),
+		filepath.ToSlash(contracts) + "/../secret.txt",
+		"/etc/passwd",
+	} {
+		result := callback(url)
+		assert.Equal(t, "File outside of allowed directories", result.Error, url)
+		assert.Empty(t, result.Contents, url)
+	}
+
+	// and so are links pointing outside
+	link := filepath.Join(contracts, "link.txt")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	assert.Equal(t, "File outside of allowed directories", callback(filepath.ToSlash(link)).Error)
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.sol", "Token.sol", true},
+		{"*.sol", "lib/Token.sol", false},
+		{"**/*.sol", "Token.sol", true},
+		{"**/*.sol", "lib/deep/Token.sol", true},
+		{"lib/**", "lib/a/b.sol", true},
+		{"lib/*/b.sol", "lib/a/b.sol", true},
+		{"src/**/Test*.sol", "src/TestA.sol", true},
+		{"src/**/Test*.sol", "other/TestA.sol", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchAnyGlob([]string{tt.pattern}, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
+
+// keys returns the keys of a sources map.
+func keys(sources map[string]SourceIn) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	return names
+}
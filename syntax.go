@@ -0,0 +1,39 @@
+package solc
+
+// syntaxCheckSource is the source unit name of the source checked by CheckSyntax.
+const syntaxCheckSource = "source.sol"
+
+// syntaxCheckers keeps one warm compiler per version for CheckSyntax.
+var syntaxCheckers = NewMultiCompiler()
+
+// CheckSyntax parses a Solidity source with the given compiler version and
+// returns the diagnostics, without analysis or code generation, e.g. to check
+// whether a file parses on every keystroke in an editor. Diagnostics refer to
+// the source as "source.sol". The first call for a version creates the
+// compiler, which is then kept for the lifetime of the process, so later calls
+// only pay for the parse. Compilers before 0.7.3, which lack stopAfter, are
+// run with an empty output selection instead.
+func CheckSyntax(version, source string) ([]Error, error) {
+	compiler, err := syntaxCheckers.compiler(version)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &Input{
+		Language: "Solidity",
+		Sources: map[string]SourceIn{
+			syntaxCheckSource: {Content: source},
+		},
+	}
+	if compiler.Supports(FeatureStopAfter) {
+		input.Settings.StopAfter = "parsing"
+	}
+	output, err := compiler.CompileWithOptions(input, &CompileOptions{
+		NoDefaultOutputSelection: true,
+		AllowMissingImports:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Errors, nil
+}
@@ -0,0 +1,34 @@
+package solc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	diagnostics, err := CheckSyntax("0.8.21", constructorContract)
+	require.NoError(t, err)
+	for _, d := range diagnostics {
+		assert.False(t, d.IsError(), d.Message)
+	}
+
+	// Only parsing happens, so type errors and missing imports go unreported
+	start := time.Now()
+	diagnostics, err = CheckSyntax("0.8.21", `pragma solidity ^0.8.0;
+import "./Missing.sol";
+contract A { function f() public { uint x = undefinedVar; } }`)
+	require.NoError(t, err)
+	for _, d := range diagnostics {
+		assert.False(t, d.IsError(), d.Message)
+	}
+	assert.Less(t, time.Since(start), time.Second, "A warm instance should be reused")
+
+	diagnostics, err = CheckSyntax("0.8.21", "pragma solidity ^0.8.0;\ncontract Broken {")
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "ParserError", diagnostics[0].Type)
+	assert.Equal(t, syntaxCheckSource, diagnostics[0].SourceLocation.File)
+}
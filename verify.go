@@ -0,0 +1,75 @@
+package solc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// VerifyBytecode reports whether deployedRuntime, the runtime code of a
+// deployed contract as returned by eth_getCode, was produced by the compiled
+// contract. The trailing metadata is stripped from both sides, since it
+// embeds source hashes that do not affect execution, and the parts filled in
+// on deployment are taken from the deployed code before comparing: immutable
+// values, linked library addresses and the address a library embeds in its
+// own code.
+//
+// The contract must have evm.deployedBytecode.object selected, and
+// evm.deployedBytecode.immutableReferences when it has immutable variables.
+func VerifyBytecode(contract *Contract, deployedRuntime []byte) (bool, error) {
+	if contract == nil {
+		return false, fmt.Errorf("contract cannot be nil")
+	}
+	compiled := strings.TrimPrefix(contract.EVM.DeployedBytecode.Object, "0x")
+	if compiled == "" {
+		return false, fmt.Errorf("contract has no deployed bytecode, make sure evm.deployedBytecode.object is selected")
+	}
+	if len(deployedRuntime) == 0 {
+		return false, fmt.Errorf("deployed runtime bytecode is empty")
+	}
+
+	deployed := hex.EncodeToString(deployedRuntime)
+
+	// Zero the immutable values, which the compiled code leaves as zeros
+	zeroed := []byte(deployed)
+	for _, offsets := range contract.EVM.DeployedBytecode.ImmutableReferences {
+		for _, offset := range offsets {
+			start, end := offset.Start*2, (offset.Start+offset.Length)*2
+			if end > len(zeroed) {
+				return false, nil
+			}
+			for i := start; i < end; i++ {
+				zeroed[i] = '0'
+			}
+		}
+	}
+	deployed = string(zeroed)
+
+	// Take linked library addresses from the deployed code
+	const placeholderLength = 40
+	masked := []byte(compiled)
+	for i := strings.Index(compiled, "__"); i >= 0; {
+		if i+placeholderLength > len(deployed) || i+placeholderLength > len(compiled) {
+			return false, nil
+		}
+		copy(masked[i:], deployed[i:i+placeholderLength])
+		next := strings.Index(compiled[i+placeholderLength:], "__")
+		if next < 0 {
+			break
+		}
+		i += placeholderLength + next
+	}
+	compiled = string(masked)
+
+	// Libraries start with PUSH20 of their own address, filled in on deployment
+	libraryPrefix := "73" + strings.Repeat("0", placeholderLength)
+	if strings.HasPrefix(compiled, libraryPrefix) && len(deployed) >= len(libraryPrefix) {
+		compiled = deployed[:len(libraryPrefix)] + compiled[len(libraryPrefix):]
+	}
+
+	if _, err := hex.DecodeString(compiled); err != nil {
+		return false, fmt.Errorf("invalid deployed bytecode hex: %w", err)
+	}
+
+	return stripMetadata(compiled) == stripMetadata(deployed), nil
+}
@@ -0,0 +1,103 @@
+package solc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBytecode(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Immutable.sol": {Content: immutableContract},
+	}, "evm.deployedBytecode.object", "evm.deployedBytecode.immutableReferences")
+	require.False(t, output.HasErrors(), output.Errors)
+	contract := output.Contracts["Immutable.sol"]["Immutable"]
+
+	runtime, err := hex.DecodeString(contract.EVM.DeployedBytecode.Object)
+	require.NoError(t, err)
+	deployed := func() []byte {
+		return append([]byte(nil), runtime...)
+	}
+
+	// The compiled code itself verifies
+	ok, err := VerifyBytecode(&contract, deployed())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Immutable values filled in on deployment are ignored
+	onChain := deployed()
+	for _, offsets := range contract.EVM.DeployedBytecode.ImmutableReferences {
+		for _, offset := range offsets {
+			big.NewInt(12345).FillBytes(onChain[offset.Start : offset.Start+offset.Length])
+		}
+	}
+	ok, err = VerifyBytecode(&contract, onChain)
+	require.NoError(t, err)
+	assert.True(t, ok, "Immutable values should be ignored")
+
+	// A different metadata hash is ignored
+	onChain = deployed()
+	onChain[len(onChain)-10] ^= 0xff
+	ok, err = VerifyBytecode(&contract, onChain)
+	require.NoError(t, err)
+	assert.True(t, ok, "Metadata should be ignored")
+
+	// A change in the code is detected
+	onChain = deployed()
+	onChain[5] ^= 0xff
+	ok, err = VerifyBytecode(&contract, onChain)
+	require.NoError(t, err)
+	assert.False(t, ok, "Code changes should be detected")
+
+	ok, err = VerifyBytecode(&contract, runtime[:len(runtime)/2])
+	require.NoError(t, err)
+	assert.False(t, ok, "Truncated code should not verify")
+
+	_, err = VerifyBytecode(&contract, nil)
+	assert.Error(t, err)
+	_, err = VerifyBytecode(&Contract{}, runtime)
+	assert.ErrorContains(t, err, "evm.deployedBytecode.object")
+}
+
+func TestVerifyBytecodeLibrary(t *testing.T) {
+	output := compileSources(t, "0.8.21", map[string]SourceIn{
+		"Calculator.sol": {Content: contractWithImport},
+		"lib/Math.sol":   {Content: mathLibrary},
+	}, "evm.deployedBytecode.object")
+	require.False(t, output.HasErrors(), output.Errors)
+	library := output.Contracts["lib/Math.sol"]["Math"]
+
+	// The library embeds its own address, which is set on deployment
+	onChain, err := hex.DecodeString(library.EVM.DeployedBytecode.Object)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x73), onChain[0])
+	copy(onChain[1:21], []byte("0123456789abcdefghij"))
+
+	ok, err := VerifyBytecode(&library, onChain)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestVerifyBytecodeLinkedLibrary(t *testing.T) {
+	placeholder := "__$" + strings.Repeat("a", 34) + "$__"
+	contract := &Contract{EVM: EVM{DeployedBytecode: DeployedBytecode{Bytecode: Bytecode{
+		Object: "608060405273" + placeholder + "5600",
+	}}}}
+
+	address := strings.Repeat("12", 20)
+	onChain, err := hex.DecodeString("608060405273" + address + "5600")
+	require.NoError(t, err)
+
+	ok, err := VerifyBytecode(contract, onChain)
+	require.NoError(t, err)
+	assert.True(t, ok, "Linked library addresses should be taken from the deployed code")
+
+	onChain[len(onChain)-1] = 0x01
+	ok, err = VerifyBytecode(contract, onChain)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
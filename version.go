@@ -0,0 +1,80 @@
+package solc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed major.minor.patch compiler version.
+type semver struct {
+	major, minor, patch int
+}
+
+// parseSemver parses a version like "0.8.21", ignoring any "+commit..." or
+// "-nightly..." suffix and a leading "v".
+func parseSemver(version string) (semver, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(v, "+-"); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("invalid version %q", version)
+	}
+
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("invalid version %q", version)
+		}
+		nums[i] = n
+	}
+
+	return semver{major: nums[0], minor: nums[1], patch: nums[2]}, nil
+}
+
+// compare returns -1, 0 or 1 if v is lower than, equal to or greater than o.
+func (v semver) compare(o semver) int {
+	switch {
+	case v.major != o.major:
+		return compareInt(v.major, o.major)
+	case v.minor != o.minor:
+		return compareInt(v.minor, o.minor)
+	default:
+		return compareInt(v.patch, o.patch)
+	}
+}
+
+// String returns the version in major.minor.patch form.
+func (v semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseCompilerVersion splits a compiler version string such as
+// "0.8.21+commit.d9974bed.Emscripten.clang" into its short ("0.8.21") and
+// long ("0.8.21+commit.d9974bed") forms.
+func parseCompilerVersion(version string) (short, long string) {
+	long = version
+	if i := strings.Index(long, ".Emscripten"); i >= 0 {
+		long = long[:i]
+	}
+	short = long
+	if i := strings.Index(short, "+"); i >= 0 {
+		short = short[:i]
+	}
+	return short, long
+}
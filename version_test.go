@@ -0,0 +1,36 @@
+package solc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompilerVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		short   string
+		long    string
+	}{
+		{"0.8.21+commit.d9974bed.Emscripten.clang", "0.8.21", "0.8.21+commit.d9974bed"},
+		{"0.4.26+commit.4563c3fc.Emscripten.clang", "0.4.26", "0.4.26+commit.4563c3fc"},
+		{"0.8.22-nightly.2023.7.20+commit.3bce0628.Emscripten.clang", "0.8.22-nightly.2023.7.20", "0.8.22-nightly.2023.7.20+commit.3bce0628"},
+		{"0.8.21", "0.8.21", "0.8.21"},
+	}
+
+	for _, tt := range tests {
+		short, long := parseCompilerVersion(tt.version)
+		assert.Equal(t, tt.short, short, tt.version)
+		assert.Equal(t, tt.long, long, tt.version)
+	}
+}
+
+func TestShortAndLongVersion(t *testing.T) {
+	compiler, err := NewWithVersion("0.8.21")
+	require.NoError(t, err)
+	defer compiler.Close()
+
+	assert.Equal(t, "0.8.21", compiler.ShortVersion())
+	assert.Equal(t, "0.8.21+commit.d9974bed", compiler.LongVersion())
+}